@@ -0,0 +1,251 @@
+package netgear
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogEntry is an entry from the routers system log, such as a DHCP lease,
+// admin login, or blocked attack
+type LogEntry struct {
+	Time    time.Time
+	Kind    string
+	MAC     net.HardwareAddr
+	IP      net.IP
+	Message string
+}
+
+var (
+	logKindPattern = regexp.MustCompile(`^\[([^\]:]+)`)
+	logMACPattern  = regexp.MustCompile(`(?i)\b[0-9a-f]{2}(:[0-9a-f]{2}){5}\b`)
+	logIPPattern   = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}\b`)
+	logTimePattern = regexp.MustCompile(`[A-Z][a-z]+day, [A-Z][a-z]{2} \d{2},\s?\d{4} \d{2}:\d{2}:\d{2}$`)
+)
+
+// ParseLogEntry parses a line of the routers system log, for example
+//
+//	[DHCP IP: (192.168.1.5)] to MAC address aa:bb:cc:dd:ee:ff, Saturday, Jan 01,2022 12:00:00
+//
+// Log times have no zone and are parsed in loc. Lines not starting with a
+// bracketed kind are rejected.
+func ParseLogEntry(line string, loc *time.Location) (LogEntry, bool) {
+	line = strings.TrimSpace(line)
+
+	kind := logKindPattern.FindStringSubmatch(line)
+	if kind == nil {
+		return LogEntry{}, false
+	}
+
+	entry := LogEntry{Kind: strings.TrimSpace(kind[1]), Message: line}
+
+	if mac := logMACPattern.FindString(line); mac != "" {
+		entry.MAC, _ = net.ParseMAC(mac)
+	}
+
+	if ip := logIPPattern.FindString(line); ip != "" {
+		entry.IP = net.ParseIP(ip)
+	}
+
+	if t, ok := parseLogTime(line, loc); ok {
+		entry.Time = t
+	} else {
+		entry.Time = time.Now()
+	}
+
+	return entry, true
+}
+
+// parseLogTime parses the timestamp at the end of a system log line
+func parseLogTime(line string, loc *time.Location) (time.Time, bool) {
+	stamp := logTimePattern.FindString(strings.TrimSpace(line))
+	if stamp == "" {
+		return time.Time{}, false
+	}
+
+	// Some firmware includes a space before the year
+	for _, layout := range []string{"Monday, Jan 02,2006 15:04:05", "Monday, Jan 02, 2006 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, stamp, loc); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Activity is a device change along with the router log entries correlated
+// with it. Log entries which could not be correlated with a device change are
+// reported as an Activity without a Change.
+type Activity struct {
+	Time   time.Time
+	Change *ChangedDevice
+	Logs   []LogEntry
+}
+
+type pendingActivity struct {
+	activity Activity
+	macs     map[string]bool
+	ips      map[string]bool
+	timer    *time.Timer
+}
+
+// matches checks if an event occurring at t belongs to the activity, by
+// address and by occurring within the window of the activity
+func (p *pendingActivity) matches(mac net.HardwareAddr, ip net.IP, t time.Time, window time.Duration) bool {
+	if d := t.Sub(p.activity.Time); d > window || d < -window {
+		return false
+	}
+
+	return (mac != nil && p.macs[mac.String()]) || (ip != nil && p.ips[ip.String()])
+}
+
+func (p *pendingActivity) track(mac net.HardwareAddr, ip net.IP) {
+	if mac != nil {
+		p.macs[mac.String()] = true
+	}
+	if ip != nil {
+		p.ips[ip.String()] = true
+	}
+}
+
+// ActivityFeed merges device changes with router log entries for the same
+// device, by MAC or IP address, occurring within a window of each other. Log
+// entries are correlated by the time they were logged, and changes by the
+// time they were reported. Each Activity is held for the window after its
+// first event to collect the events which follow it.
+type ActivityFeed struct {
+	window time.Duration
+	out    chan Activity
+	done   chan struct{}
+
+	mu      sync.Mutex
+	pending []*pendingActivity
+	closed  bool
+	flushes sync.WaitGroup
+
+	closeOnce sync.Once
+}
+
+// NewActivityFeed constructs an ActivityFeed correlating events within the
+// window. Attach Listener to a Watcher and provide log entries using AddLog.
+func NewActivityFeed(window time.Duration) *ActivityFeed {
+	return &ActivityFeed{
+		window: window,
+		out:    make(chan Activity, 64),
+		done:   make(chan struct{}),
+	}
+}
+
+// Activity is the stream of correlated activity. It is closed by Close.
+func (f *ActivityFeed) Activity() <-chan Activity {
+	return f.out
+}
+
+// Listener is the DeviceListener adding device changes to the feed
+func (f *ActivityFeed) Listener() DeviceListener {
+	return func(change *ChangedDevice, err error) {
+		if err != nil || change.Change == DeviceSeen {
+			return
+		}
+
+		changed := *change
+
+		// A later change for the same device starts its own activity rather
+		// than replacing the change already collected.
+		f.add(changed.Device.MAC, changed.Device.IP, time.Now(), func(a *Activity) {
+			a.Change = &changed
+		}, func(a *Activity) bool { return a.Change == nil })
+	}
+}
+
+// AddLog adds a router log entry to the feed
+func (f *ActivityFeed) AddLog(entry LogEntry) {
+	f.add(entry.MAC, entry.IP, entry.Time, func(a *Activity) {
+		a.Logs = append(a.Logs, entry)
+	}, func(*Activity) bool { return true })
+}
+
+func (f *ActivityFeed) add(mac net.HardwareAddr, ip net.IP, t time.Time, merge func(*Activity), accepts func(*Activity) bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+
+	for _, p := range f.pending {
+		if p.matches(mac, ip, t, f.window) && accepts(&p.activity) {
+			merge(&p.activity)
+			p.track(mac, ip)
+			return
+		}
+	}
+
+	p := &pendingActivity{
+		activity: Activity{Time: t},
+		macs:     map[string]bool{},
+		ips:      map[string]bool{},
+	}
+	merge(&p.activity)
+	p.track(mac, ip)
+
+	f.pending = append(f.pending, p)
+	f.flushes.Add(1)
+
+	p.timer = time.AfterFunc(f.window, func() {
+		defer f.flushes.Done()
+		f.flush(p)
+	})
+}
+
+func (f *ActivityFeed) flush(p *pendingActivity) {
+	f.mu.Lock()
+	for i, pending := range f.pending {
+		if pending == p {
+			f.pending = append(f.pending[:i], f.pending[i+1:]...)
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	// Once closed, activity is only reported while the stream has room
+	select {
+	case f.out <- p.activity:
+		return
+	default:
+	}
+
+	select {
+	case f.out <- p.activity:
+	case <-f.done:
+	}
+}
+
+// Close stops accepting events and reports pending activity without waiting
+// out its window, then closes the Activity stream. Activity which does not
+// fit in the stream is dropped rather than waiting for it to be read. Calling
+// Close more than once has no further effect.
+func (f *ActivityFeed) Close() {
+	f.closeOnce.Do(f.close)
+}
+
+func (f *ActivityFeed) close() {
+	f.mu.Lock()
+	f.closed = true
+	pending := append([]*pendingActivity{}, f.pending...)
+	f.mu.Unlock()
+
+	close(f.done)
+
+	for _, p := range pending {
+		if p.timer.Stop() {
+			f.flush(p)
+			f.flushes.Done()
+		}
+	}
+
+	f.flushes.Wait()
+	close(f.out)
+}
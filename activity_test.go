@@ -0,0 +1,77 @@
+package netgear_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func TestActivityFeedLogTime(t *testing.T) {
+	feed := netgear.NewActivityFeed(time.Minute)
+
+	mac := netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")
+	logged := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Entries are correlated by when they were logged, not when they were
+	// added to the feed
+	feed.AddLog(netgear.LogEntry{Time: logged, Kind: "DHCP IP", MAC: mac})
+	feed.AddLog(netgear.LogEntry{Time: logged.Add(30 * time.Second), Kind: "Admin login", MAC: mac})
+	feed.AddLog(netgear.LogEntry{Time: logged.Add(time.Hour), Kind: "DHCP IP", MAC: mac})
+
+	feed.Close()
+
+	activity := []netgear.Activity{}
+	for a := range feed.Activity() {
+		activity = append(activity, a)
+	}
+
+	if len(activity) != 2 {
+		t.Fatalf("Expected 2 activities, got %d", len(activity))
+	}
+
+	if !activity[0].Time.Equal(logged) || len(activity[0].Logs) != 2 {
+		t.Errorf("Expected the first two entries correlated at %s, got %+v", logged, activity[0])
+	}
+
+	if len(activity[1].Logs) != 1 {
+		t.Errorf("Expected the later entry on its own, got %+v", activity[1])
+	}
+}
+
+func TestActivityFeedCloseUnread(t *testing.T) {
+	feed := netgear.NewActivityFeed(10 * time.Millisecond)
+
+	// Fill the stream past its buffer without reading it
+	for i := 0; i < 100; i++ {
+		mac := netgeartest.MustMAC(t, fmt.Sprintf("aa:bb:cc:00:00:%02x", i))
+		feed.AddLog(netgear.LogEntry{Time: time.Now(), Kind: "DHCP IP", MAC: mac})
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		feed.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close blocked on unread activity")
+	}
+}
+
+func TestActivityFeedCloseTwice(t *testing.T) {
+	feed := netgear.NewActivityFeed(time.Minute)
+
+	feed.Close()
+	feed.Close()
+
+	if _, ok := <-feed.Activity(); ok {
+		t.Error("Expected the activity stream to be closed")
+	}
+}
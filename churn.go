@@ -0,0 +1,120 @@
+package netgear
+
+import (
+	"sort"
+	"time"
+)
+
+// churnRetention is how long device changes are kept for computing churn
+// statistics. Windows larger than this will only report the retained history.
+const churnRetention = 24 * time.Hour
+
+type churnEvent struct {
+	MAC    string
+	Change DeviceChange
+	At     time.Time
+}
+
+// DeviceChurn summarizes how often a device joined and left the network
+type DeviceChurn struct {
+	MAC        string
+	Joins      int
+	Leaves     int
+	AvgSession time.Duration
+}
+
+// Flaps is the total number of times the device joined or left the network
+func (d DeviceChurn) Flaps() int {
+	return d.Joins + d.Leaves
+}
+
+func (w *Watcher) recordChurn(changes []ChangedDevice, now time.Time) {
+	for _, change := range changes {
+		// Only joins and leaves are churn, updates would be reported as
+		// devices which never flapped
+		if change.Change != DeviceAdded && change.Change != DeviceRemoved {
+			continue
+		}
+
+		w.churn = append(w.churn, churnEvent{
+			MAC:    change.Device.MAC.String(),
+			Change: change.Change,
+			At:     now,
+		})
+	}
+
+	// Drop events that have fallen out of the retention period. Events are
+	// recorded in order so we only need to find the first retained event.
+	cutoff := now.Add(-churnRetention)
+	expired := sort.Search(len(w.churn), func(i int) bool {
+		return w.churn[i].At.After(cutoff)
+	})
+
+	w.churn = w.churn[expired:]
+}
+
+// ChurnStats reports how often each device joined and left the network within
+// the window, ordered with the flappiest devices first. The average session
+// duration includes only sessions which ended within the window.
+func (w *Watcher) ChurnStats(window time.Duration) []DeviceChurn {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	cutoff := time.Now().Add(-window)
+
+	stats := map[string]*DeviceChurn{}
+	joined := map[string]time.Time{}
+	sessions := map[string][]time.Duration{}
+
+	for _, event := range w.churn {
+		// Joins prior to the window are still tracked to compute the
+		// duration of sessions ending within the window.
+		if event.At.Before(cutoff) {
+			if event.Change == DeviceAdded {
+				joined[event.MAC] = event.At
+			}
+			continue
+		}
+
+		stat, ok := stats[event.MAC]
+		if !ok {
+			stat = &DeviceChurn{MAC: event.MAC}
+			stats[event.MAC] = stat
+		}
+
+		switch event.Change {
+		case DeviceAdded:
+			stat.Joins++
+			joined[event.MAC] = event.At
+		case DeviceRemoved:
+			stat.Leaves++
+			if start, ok := joined[event.MAC]; ok {
+				sessions[event.MAC] = append(sessions[event.MAC], event.At.Sub(start))
+				delete(joined, event.MAC)
+			}
+		}
+	}
+
+	churn := make([]DeviceChurn, 0, len(stats))
+
+	for mac, stat := range stats {
+		if len(sessions[mac]) > 0 {
+			var total time.Duration
+			for _, session := range sessions[mac] {
+				total += session
+			}
+			stat.AvgSession = total / time.Duration(len(sessions[mac]))
+		}
+
+		churn = append(churn, *stat)
+	}
+
+	sort.Slice(churn, func(i, j int) bool {
+		if churn[i].Flaps() != churn[j].Flaps() {
+			return churn[i].Flaps() > churn[j].Flaps()
+		}
+		return churn[i].MAC < churn[j].MAC
+	})
+
+	return churn
+}
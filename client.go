@@ -2,79 +2,63 @@ package netgear
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
-	"text/template"
-)
+	"sync"
+	"time"
 
-const soapLogin = `
-<?xml version="1.0" encoding="utf-8" ?>
-<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://schemas.xmlsoap.org/soap/envelope/">
-<SOAP-ENV:Header>
-<SessionID xsi:type="xsd:string"
-  xmlns:xsi="http://www.w3.org/1999/XMLSchema-instance">{{.sessionID}}</SessionID>
-</SOAP-ENV:Header>
-<SOAP-ENV:Body>
-<Authenticate>
-  <NewUsername>{{.username}}</NewUsername>
-  <NewPassword>{{.password}}</NewPassword>
-</Authenticate>
-</SOAP-ENV:Body>
-</SOAP-ENV:Envelope>`
-
-const soapAttachedDev = `
-<?xml version="1.0" encoding="utf-8" standalone="no"?>
-<SOAP-ENV:Envelope xmlns:SOAPSDK1="http://www.w3.org/2001/XMLSchema"
-  xmlns:SOAPSDK2="http://www.w3.org/2001/XMLSchema-instance"
-  xmlns:SOAPSDK3="http://schemas.xmlsoap.org/soap/encoding/"
-  xmlns:SOAP-ENV="http://schemas.xmlsoap.org/soap/envelope/">
-<SOAP-ENV:Header>
-<SessionID>{{.sessionID}}</SessionID>
-</SOAP-ENV:Header>
-<SOAP-ENV:Body>
-<M1:GetAttachDevice xmlns:M1="urn:NETGEAR-ROUTER:service:DeviceInfo:1">
-</M1:GetAttachDevice>
-</SOAP-ENV:Body>
-</SOAP-ENV:Envelope>`
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
 
 // DefaultSessionID is  taken from the pynetgear library. Apparently it's
 // unknown how to generate this
 const DefaultSessionID = "A7D88AE69687E58D9A00"
 
-type soapAction string
-
-const (
-	loginAction       soapAction = "urn:NETGEAR-ROUTER:service:ParentalControl:1#Authenticate"
-	attachedDevAction soapAction = "urn:NETGEAR-ROUTER:service:DeviceInfo:1#GetAttachDevice"
-)
-
-var (
-	loginTemplate, _       = template.New("login").Parse(soapLogin)
-	attachedDevTemplate, _ = template.New("attachedDev").Parse(soapAttachedDev)
-)
-
-// Map actions to the templates they should render
-var soapTemplates = map[soapAction]*template.Template{
-	loginAction:       loginTemplate,
-	attachedDevAction: attachedDevTemplate,
-}
-
-type soapResponseCode struct {
-	ResponseCode int `xml:"ResponseCode"`
-}
-
-// AttachedDevice represents a device attached to the router
+// AttachedDevice represents a device attached to the router. The detailed
+// fields are only populated by DetailedDevices.
 type AttachedDevice struct {
 	IP       net.IP
+	IPv6     []net.IP
 	Name     string
+	Label    string // Display name, resolved using the clients NameChain
 	MAC      net.HardwareAddr
 	Type     string
 	LinkRate int
 	Signal   int
+	Quality  int // Connection quality score from 0 to 100, see QualityThresholds
+	Meta     DeviceMeta
+
+	// Detailed fields
+	ConnectionType string
+	SSID           string
+	AccessPoint    net.HardwareAddr
+	Model          string
+	Upload         float64
+	Download       float64
+}
+
+// mergeDetails copies the detailed fields from another device
+func (d *AttachedDevice) mergeDetails(detailed AttachedDevice) {
+	d.ConnectionType = detailed.ConnectionType
+	d.SSID = detailed.SSID
+	d.AccessPoint = detailed.AccessPoint
+	d.Model = detailed.Model
+	d.Upload = detailed.Upload
+	d.Download = detailed.Download
+}
+
+// IsRandomized reports if the device is using a locally administered MAC
+// address. Modern phones randomize their address per network, and may rotate
+// it periodically, so it is not a stable identifier for the device. Disabling
+// randomization for the home network on the device restores a stable address.
+func (d AttachedDevice) IsRandomized() bool {
+	return len(d.MAC) > 0 && d.MAC[0]&0x02 != 0
 }
 
 // Client is a API client used to talk to a netgear router
@@ -84,37 +68,67 @@ type Client struct {
 	Port      int
 	Username  string
 	Password  string
+
+	httpClient *http.Client
+	dial       dialConfig
+	envelope   Envelope
+	names      NameChain
+	macFormat  MACFormat
+	privacy    *Privacy
+	quality    *QualityThresholds
+	duplicates DuplicatePolicy
+
+	configMu sync.Mutex
+
+	mu          sync.Mutex
+	model       string
+	parsers     map[soapAction]ResponseParser
+	negotiated  map[soapService]int
+	configSince time.Time
+	configDone  chan struct{}
+	shared      map[time.Duration]*sharedWatcher
+	lifecycle   lifecycle
+
+	// paused are the devices paused by the client, and pauseEnabled is set
+	// when access control was enabled to pause them
+	paused       map[string]bool
+	pauseEnabled bool
 }
 
 // NewClient constructs a new netgear.Client initalized with default values
-func NewClient(host, username, password string) *Client {
-	return &Client{
+func NewClient(host, username, password string, opts ...ClientOption) *Client {
+	c := &Client{
 		SessionID: DefaultSessionID,
 		Host:      host,
-		Port:      5000,
+		Port:      soapconst.DefaultPort,
 		Username:  username,
 		Password:  password,
 	}
-}
 
-func (c *Client) soap(action soapAction, params interface{}) (*http.Response, error) {
-	templateBody := &bytes.Buffer{}
-	soapTemplates[action].Execute(templateBody, params)
-
-	url := fmt.Sprintf("http://%s:%d/soap/server_sa", c.Host, c.Port)
-	req, err := http.NewRequest("POST", url, templateBody)
-	if err != nil {
-		return nil, err
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	req.Header.Add("SOAPAction", string(action))
+	c.httpClient = c.dial.httpClient()
 
-	return http.DefaultClient.Do(req)
+	return c
+}
+
+func (c *Client) getHTTPClient() *http.Client {
+	if c.httpClient == nil {
+		return http.DefaultClient
+	}
+
+	return c.httpClient
 }
 
 // Login authenticates the client session to the router
 func (c *Client) Login() error {
-	resp, err := c.soap(loginAction, map[string]string{
+	return c.login(context.Background())
+}
+
+func (c *Client) login(ctx context.Context) error {
+	resp, err := c.soapContext(ctx, loginAction, map[string]string{
 		"sessionID": c.SessionID,
 		"username":  c.Username,
 		"password":  c.Password,
@@ -122,6 +136,7 @@ func (c *Client) Login() error {
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	type soapBody struct {
 		soapResponseCode
@@ -138,19 +153,63 @@ func (c *Client) Login() error {
 
 	respCode := envelope.Body.ResponseCode
 	if respCode != 0 {
-		return fmt.Errorf("Unable to login, got status code %d", respCode)
+		return &ResponseError{Op: "login", Code: respCode, Action: string(loginAction)}
 	}
 
 	return nil
 }
 
-// Devices gets a list of devices attached to the router
+// DeviceList is the list of attached devices reported by the router, along
+// with the device count the router prefixed the list with
+type DeviceList struct {
+	Devices  []AttachedDevice
+	Reported int
+
+	// Duplicates is the number of entries removed by the clients
+	// DuplicatePolicy
+	Duplicates int
+}
+
+// Listed is the number of devices listed by the router, including removed
+// duplicates
+func (l *DeviceList) Listed() int {
+	return len(l.Devices) + l.Duplicates
+}
+
+// Complete indicates the number of listed devices matches the count reported
+// by the router. A mismatch indicates the response was truncated.
+func (l *DeviceList) Complete() bool {
+	return l.Listed() == l.Reported
+}
+
+// Devices gets a list of devices attached to the router, ordered by MAC
+// address. A DeviceCountError is returned when the list is incomplete.
 func (c *Client) Devices() ([]AttachedDevice, error) {
-	resp, err := c.soap(attachedDevAction, map[string]string{"sessionID": c.SessionID})
+	list, err := c.DeviceList()
 	if err != nil {
 		return nil, err
 	}
 
+	if !list.Complete() {
+		return nil, &DeviceCountError{Reported: list.Reported, Parsed: list.Listed()}
+	}
+
+	return list.Devices, nil
+}
+
+// DeviceList gets the list of devices attached to the router, ordered by MAC
+// address, without verifying the list is complete
+func (c *Client) DeviceList() (*DeviceList, error) {
+	return c.deviceList(context.Background())
+}
+
+func (c *Client) deviceList(ctx context.Context) (*DeviceList, error) {
+	resp, err := c.soapContext(ctx, attachedDevAction, map[string]string{"sessionID": c.SessionID})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
 	type soapDevices struct {
 		AttachedDevices string `xml:"NewAttachDevice"`
 	}
@@ -171,21 +230,178 @@ func (c *Client) Devices() ([]AttachedDevice, error) {
 
 	respCode := envelope.Body.ResponseCode
 	if respCode != 0 {
-		return nil, fmt.Errorf("Unable to get devices, got status code %d", respCode)
+		return nil, &ResponseError{Op: "get devices", Code: respCode, Action: string(attachedDevAction)}
+	}
+
+	list, err := parseDevicesString(envelope.Body.Devices.AttachedDevices)
+	if err != nil {
+		return nil, err
+	}
+
+	list.Devices, list.Duplicates = dedupDevices(list.Devices, c.duplicates)
+
+	c.labelDevices(list.Devices)
+	c.scoreDevices(list.Devices)
+	sortDevices(list.Devices)
+
+	return list, nil
+}
+
+// DetailedDevices gets a list of devices attached to the router, including
+// the detailed fields, ordered by MAC address. This is more expensive for the
+// router to compute than Devices and is not supported by older firmware.
+func (c *Client) DetailedDevices() ([]AttachedDevice, error) {
+	resp, err := c.soap(attachedDev2Action, map[string]string{"sessionID": c.SessionID})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	type soapBody struct {
+		soapResponseCode
+		Devices []soapDevice2 `xml:"GetAttachDevice2Response>NewAttachDevice>Device"`
+	}
+
+	type soapEnvelope struct {
+		Body soapBody `xml:"Body"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, err
+	}
+
+	respCode := envelope.Body.ResponseCode
+	if respCode != 0 {
+		return nil, &ResponseError{Op: "get detailed devices", Code: respCode, Action: string(attachedDev2Action)}
+	}
+
+	devList := make([]AttachedDevice, len(envelope.Body.Devices))
+
+	for i, dev := range envelope.Body.Devices {
+		device, err := dev.attachedDevice()
+		if err != nil {
+			return nil, err
+		}
+
+		devList[i] = device
 	}
 
-	return parseDevicesString(envelope.Body.Devices.AttachedDevices)
+	devList, _ = dedupDevices(devList, c.duplicates)
+
+	c.labelDevices(devList)
+	c.scoreDevices(devList)
+	sortDevices(devList)
+
+	return devList, nil
 }
 
-func parseDevicesString(devices string) ([]AttachedDevice, error) {
+type soapDevice2 struct {
+	IP             string `xml:"IP"`
+	Name           string `xml:"Name"`
+	MAC            string `xml:"MAC"`
+	ConnectionType string `xml:"ConnectionType"`
+	SSID           string `xml:"SSID"`
+	LinkRate       string `xml:"Linkspeed"`
+	Signal         string `xml:"SignalStrength"`
+	Model          string `xml:"DeviceModel"`
+	Upload         string `xml:"Upload"`
+	Download       string `xml:"Download"`
+	AccessPoint    string `xml:"ConnAPMAC"`
+}
+
+func (d soapDevice2) attachedDevice() (AttachedDevice, error) {
+	mac, err := net.ParseMAC(d.MAC)
+	if err != nil {
+		return AttachedDevice{}, err
+	}
+
+	signal, err := strconv.Atoi(d.Signal)
+	if err != nil && d.Signal != "" {
+		return AttachedDevice{}, err
+	}
+
+	linkRate, err := strconv.Atoi(d.LinkRate)
+	if err != nil && d.LinkRate != "" {
+		return AttachedDevice{}, err
+	}
+
+	upload, err := strconv.ParseFloat(d.Upload, 64)
+	if err != nil && d.Upload != "" {
+		return AttachedDevice{}, err
+	}
+
+	download, err := strconv.ParseFloat(d.Download, 64)
+	if err != nil && d.Download != "" {
+		return AttachedDevice{}, err
+	}
+
+	// The access point MAC is only reported for devices connected through a
+	// satellite or extender.
+	accessPoint, _ := net.ParseMAC(d.AccessPoint)
+
+	// GetAttachDevice reports wired devices with a type of "wired" and all
+	// others as "wireless", keep that consistent here.
+	devType := "wireless"
+	if strings.EqualFold(d.ConnectionType, "wired") {
+		devType = "wired"
+	}
+
+	ip, ipv6 := parseDeviceIPs(d.IP)
+
+	return AttachedDevice{
+		IP:             ip,
+		IPv6:           ipv6,
+		Name:           d.Name,
+		MAC:            mac,
+		Type:           devType,
+		Signal:         signal,
+		LinkRate:       linkRate,
+		ConnectionType: d.ConnectionType,
+		SSID:           d.SSID,
+		AccessPoint:    accessPoint,
+		Model:          d.Model,
+		Upload:         upload,
+		Download:       download,
+	}, nil
+}
+
+// sortDevices orders devices by MAC address. The router does not report
+// devices in any consistent order.
+func sortDevices(devices []AttachedDevice) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		return bytes.Compare(devices[i].MAC, devices[j].MAC) < 0
+	})
+}
+
+// sortChanges orders changes by the MAC address of the changed device,
+// keeping the order of changes to the same device
+func sortChanges(changes []ChangedDevice) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		return bytes.Compare(changes[i].Device.MAC, changes[j].Device.MAC) < 0
+	})
+}
+
+func parseDevicesString(devices string) (*DeviceList, error) {
 	// Each device in the list is separated by a '@' character.
-	// We trim the first two characters as it is just the total number of
-	// devices followed by a '@'.
-	devStrs := strings.Split(devices[2:], "@")
-	devList := make([]AttachedDevice, len(devStrs))
+	// The first entry is the total number of devices. When no devices are
+	// attached the router reports only the count.
+	devStrs := strings.Split(devices, "@")
+
+	reported, err := strconv.Atoi(strings.TrimSpace(devStrs[0]))
+	if err != nil {
+		return nil, fmt.Errorf("Device list does not start with a device count: %q", devStrs[0])
+	}
+
+	devStrs = devStrs[1:]
+	devList := make([]AttachedDevice, 0, len(devStrs))
 
 	// Each device contains eight properties separaterd by a ';' character
-	for i, devStr := range devStrs {
+	for _, devStr := range devStrs {
+		if devStr == "" {
+			continue
+		}
+
 		parts := strings.Split(devStr, ";")
 
 		if len(parts) != 8 {
@@ -207,15 +423,41 @@ func parseDevicesString(devices string) ([]AttachedDevice, error) {
 			return nil, err
 		}
 
-		devList[i] = AttachedDevice{
-			IP:       net.ParseIP(parts[1]),
+		ip, ipv6 := parseDeviceIPs(parts[1])
+
+		devList = append(devList, AttachedDevice{
+			IP:       ip,
+			IPv6:     ipv6,
 			Name:     parts[2],
 			MAC:      mac,
 			Type:     parts[4],
 			Signal:   signal,
 			LinkRate: linkRate,
+		})
+	}
+
+	return &DeviceList{Devices: devList, Reported: reported}, nil
+}
+
+// Dual-stack firmware may report more than one address for a device, separated
+// by a ',' character. The first IPv4 address is used as the device IP, all
+// IPv6 addresses are collected separately.
+func parseDeviceIPs(field string) (net.IP, []net.IP) {
+	var ip net.IP
+	var ipv6 []net.IP
+
+	for _, addr := range strings.Split(field, ",") {
+		parsed := net.ParseIP(strings.TrimSpace(addr))
+
+		switch {
+		case parsed == nil:
+			continue
+		case parsed.To4() == nil:
+			ipv6 = append(ipv6, parsed)
+		case ip == nil:
+			ip = parsed
 		}
 	}
 
-	return devList, nil
+	return ip, ipv6
 }
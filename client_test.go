@@ -0,0 +1,139 @@
+package netgear_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func TestLoginRejected(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	client := server.Client()
+	client.Password = "wrong"
+
+	err := client.Login()
+
+	respErr := &netgear.ResponseError{}
+	if !errors.As(err, &respErr) || respErr.Code != netgeartest.CodeUnauthorized {
+		t.Fatalf("Expected unauthorized response error, got %v", err)
+	}
+}
+
+func TestDevices(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(
+		netgeartest.Device(t, "aa:bb:cc:00:00:02", "192.168.1.3", "laptop"),
+		netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"),
+	)
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err := client.Devices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices) != 2 {
+		t.Fatalf("Expected 2 devices, got %d", len(devices))
+	}
+
+	// Devices are ordered by MAC address
+	tests := []struct {
+		mac, ip, name string
+	}{
+		{"aa:bb:cc:00:00:01", "192.168.1.2", "phone"},
+		{"aa:bb:cc:00:00:02", "192.168.1.3", "laptop"},
+	}
+
+	for i, tt := range tests {
+		dev := devices[i]
+
+		if dev.MAC.String() != tt.mac || dev.IP.String() != tt.ip || dev.Name != tt.name {
+			t.Errorf("Device %d: expected %s %s %s, got %s %s %s", i, tt.mac, tt.ip, tt.name, dev.MAC, dev.IP, dev.Name)
+		}
+		if dev.Signal != 80 || dev.LinkRate != 433 {
+			t.Errorf("Device %d: expected signal 80 and link rate 433, got %d and %d", i, dev.Signal, dev.LinkRate)
+		}
+		if dev.SSID != "" {
+			t.Errorf("Device %d: detailed fields should not be populated, got SSID %q", i, dev.SSID)
+		}
+	}
+}
+
+func TestDetailedDevices(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	device := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone")
+	device.Upload, device.Download = 1.5, 20.25
+	device.Model = "Pixel"
+	server.SetDevices(device)
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err := client.DetailedDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices) != 1 {
+		t.Fatalf("Expected 1 device, got %d", len(devices))
+	}
+
+	dev := devices[0]
+	if dev.SSID != "home" || dev.ConnectionType != "5GHz" || dev.Model != "Pixel" {
+		t.Errorf("Detailed fields not parsed, got %+v", dev)
+	}
+	if dev.Upload != 1.5 || dev.Download != 20.25 {
+		t.Errorf("Expected traffic 1.5/20.25, got %v/%v", dev.Upload, dev.Download)
+	}
+	if dev.Type != "wireless" {
+		t.Errorf("Expected wireless device, got %q", dev.Type)
+	}
+}
+
+func TestDevicesUnauthenticated(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	_, err := server.Client().Devices()
+
+	respErr := &netgear.ResponseError{}
+	if !errors.As(err, &respErr) || respErr.Code != netgeartest.CodeUnauthorized {
+		t.Fatalf("Expected unauthorized response error, got %v", err)
+	}
+	if respErr.Action != "DeviceInfo#GetAttachDevice" {
+		t.Errorf("Expected the failing action, got %q", respErr.Action)
+	}
+}
+
+func TestDevicesTruncated(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Devices(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.TruncateResponses(1)
+
+	if _, err := client.Devices(); err == nil {
+		t.Fatal("Expected a truncated response to fail")
+	}
+
+	if _, err := client.Devices(); err != nil {
+		t.Fatalf("Expected the following call to succeed, got %v", err)
+	}
+}
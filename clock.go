@@ -0,0 +1,109 @@
+package netgear
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrNoRouterClock is returned when the router does not report its clock
+var ErrNoRouterClock = errors.New("Router did not report its current time")
+
+// ClockSkew estimates how far the routers clock is ahead of the local clock,
+// negative when the router is behind. The router reports its time in the Date
+// header of SOAP responses, which has a resolution of one second. No login
+// attempt is made, the router info is requested to read it, so the client
+// must already be logged in.
+//
+// Schedule based features such as access schedules and the traffic meter
+// reset rely on the routers clock, and silently misbehave when it is wrong.
+func (c *Client) ClockSkew() (time.Duration, error) {
+	start := time.Now()
+
+	resp, err := c.soap(infoAction, map[string]string{
+		"sessionID": c.SessionID,
+		"elements":  "",
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Compare against the midpoint of the request to account for latency
+	local := start.Add(time.Since(start) / 2)
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("Unable to get router clock, got HTTP status %s", resp.Status)
+	}
+
+	type soapEnvelope struct {
+		Body soapResponseCode `xml:"Body"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return 0, err
+	}
+
+	respCode := envelope.Body.ResponseCode
+	if respCode != 0 {
+		return 0, &ResponseError{Op: "get router clock", Code: respCode, Action: string(infoAction)}
+	}
+
+	date := resp.Header.Get("Date")
+	if date == "" {
+		return 0, ErrNoRouterClock
+	}
+
+	routerTime, err := http.ParseTime(date)
+	if err != nil {
+		return 0, err
+	}
+
+	return routerTime.Sub(local).Round(time.Second), nil
+}
+
+// LogClockSkew estimates how far the routers clock is ahead of the local
+// clock from a line of the routers system log, received at a known local
+// time such as through syslog. Log lines are delivered within moments of
+// being logged, so the difference reflects the routers clock. False is
+// returned when the line has no timestamp. Log times have no zone and are
+// parsed in loc, which should be the time zone configured on the router.
+func LogClockSkew(line string, received time.Time, loc *time.Location) (time.Duration, bool) {
+	logged, ok := parseLogTime(line, loc)
+	if !ok {
+		return 0, false
+	}
+
+	return logged.Sub(received.Truncate(time.Second)), true
+}
+
+// TrafficMeterClockSkew checks the routers clock against the traffic meter.
+// The meter restarts its daily counters at the configured restart time of
+// the routers clock, so a connection time today longer than the local time
+// since the restart means the router is ahead by at least the difference.
+// Zero is returned when the meter is consistent with the local clock. A
+// router which is behind is not detected this way.
+func (c *Client) TrafficMeterClockSkew() (time.Duration, error) {
+	meter, err := c.TrafficMeter()
+	if err != nil {
+		return 0, err
+	}
+
+	return meterClockSkew(meter.Today), nil
+}
+
+// meterSkewTolerance allows for the minute resolution of connection times
+const meterSkewTolerance = time.Minute
+
+func meterClockSkew(today TrafficPeriod) time.Duration {
+	elapsed := today.End.Sub(today.Start)
+
+	if ahead := today.ConnectionTime - elapsed; ahead > meterSkewTolerance {
+		return ahead.Round(time.Minute)
+	}
+
+	return 0
+}
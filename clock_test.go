@@ -0,0 +1,98 @@
+package netgear_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func TestClockSkew(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetClockOffset(10 * time.Minute)
+
+	client := server.Client()
+
+	_, err := client.ClockSkew()
+
+	respErr := &netgear.ResponseError{}
+	if !errors.As(err, &respErr) || respErr.Code != netgeartest.CodeUnauthorized {
+		t.Fatalf("Expected unauthorized response error, got %v", err)
+	}
+
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	skew, err := client.ClockSkew()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The Date header has a resolution of one second
+	if diff := skew - 10*time.Minute; diff < -2*time.Second || diff > 2*time.Second {
+		t.Errorf("Expected a skew of 10m, got %s", skew)
+	}
+}
+
+func TestLogClockSkew(t *testing.T) {
+	received := time.Date(2022, time.January, 1, 12, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		line string
+		skew time.Duration
+		ok   bool
+	}{
+		{"[admin login] from source 192.168.1.5, Saturday, Jan 01,2022 12:05:00", 5 * time.Minute, true},
+		{"[DHCP IP: (192.168.1.5)] to MAC address aa:bb:cc:dd:ee:ff, Saturday, Jan 01, 2022 11:59:30", -30 * time.Second, true},
+		{"[admin login] from source 192.168.1.5", 0, false},
+	}
+
+	for _, tt := range tests {
+		skew, ok := netgear.LogClockSkew(tt.line, received, time.UTC)
+		if ok != tt.ok || skew != tt.skew {
+			t.Errorf("%q: expected %s %t, got %s %t", tt.line, tt.skew, tt.ok, skew, ok)
+		}
+	}
+}
+
+func TestTrafficMeterClockSkew(t *testing.T) {
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	elapsed := now.Sub(midnight).Truncate(time.Minute)
+
+	tests := []struct {
+		name     string
+		connTime time.Duration
+		min, max time.Duration
+	}{
+		{"consistent", elapsed, 0, 0},
+		{"ahead", elapsed + 2*time.Hour, 119 * time.Minute, 120 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := netgeartest.Start(t)
+			server.SetTrafficMeter(
+				netgear.TrafficMeterOptions{ControlOption: "No limit", RestartDay: 1},
+				netgear.TrafficMeter{Today: netgear.TrafficPeriod{ConnectionTime: tt.connTime}},
+			)
+
+			client := server.Client()
+			if err := client.Login(); err != nil {
+				t.Fatal(err)
+			}
+
+			skew, err := client.TrafficMeterClockSkew()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if skew < tt.min || skew > tt.max {
+				t.Errorf("Expected skew between %s and %s, got %s", tt.min, tt.max, skew)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package netgear
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrClientClosed is returned when making calls on a closed Client
+var ErrClientClosed = errors.New("Client is closed")
+
+// logoutTimeout bounds how long Close waits for the router to end the session
+const logoutTimeout = 5 * time.Second
+
+// lifecycle tracks in-flight calls and watchers so that a Client may be shut
+// down cleanly. The zero value is ready to use.
+type lifecycle struct {
+	mu       sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	closed   bool
+	inflight sync.WaitGroup
+	watchers []stopper
+}
+
+// stopper is a watcher started from the client, stopped when it is closed.
+// halt signals the watcher to stop and wait waits for it to exit, so that
+// every watcher is signalled before in-flight calls are cancelled.
+type stopper interface {
+	halt()
+	wait()
+}
+
+func (l *lifecycle) init() {
+	if l.ctx == nil {
+		l.ctx, l.cancel = context.WithCancel(context.Background())
+	}
+}
+
+// begin marks the start of a call, returning the context the call should be
+// made with. end must be called once the call completes, including reading
+// the response body.
+func (l *lifecycle) begin() (context.Context, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil, ErrClientClosed
+	}
+
+	l.init()
+	l.inflight.Add(1)
+
+	return l.ctx, nil
+}
+
+// closing returns a context done once the client is closed
+func (l *lifecycle) closing() (context.Context, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil, ErrClientClosed
+	}
+
+	l.init()
+
+	return l.ctx, nil
+}
+
+func (l *lifecycle) end() {
+	l.inflight.Done()
+}
+
+// trackedBody ends an in-flight call once the response body is closed
+type trackedBody struct {
+	io.ReadCloser
+	once sync.Once
+	end  func()
+}
+
+func (b *trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.end)
+
+	return err
+}
+
+func (c *Client) trackWatcher(w stopper) {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+
+	c.lifecycle.watchers = append(c.lifecycle.watchers, w)
+}
+
+func (c *Client) untrackWatcher(w stopper) {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+
+	watchers := c.lifecycle.watchers[:0]
+	for _, tracked := range c.lifecycle.watchers {
+		if tracked != w {
+			watchers = append(watchers, tracked)
+		}
+	}
+
+	c.lifecycle.watchers = watchers
+}
+
+// Logout ends the client session with the router. Older firmware which does
+// not support logging out is ignored.
+func (c *Client) Logout() error {
+	ctx, err := c.lifecycle.begin()
+	if err != nil {
+		return err
+	}
+	defer c.lifecycle.end()
+
+	return c.logout(ctx)
+}
+
+func (c *Client) logout(ctx context.Context) error {
+	resp, err := c.soapNegotiate(ctx, logoutAction, map[string]string{"sessionID": c.SessionID})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	type soapEnvelope struct {
+		Body soapResponseCode `xml:"Body"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+
+	respCode := envelope.Body.ResponseCode
+	if respCode != 0 && respCode != codeNotSupported {
+		return &ResponseError{Op: "logout", Code: respCode, Action: string(logoutAction)}
+	}
+
+	return nil
+}
+
+// Close shuts down the client. Watchers started from the client are stopped,
+// in-flight calls are cancelled and waited on, and the session is logged
+// out. Any further calls made using the client will return ErrClientClosed.
+// Close must not be called from within a listener.
+func (c *Client) Close() error {
+	l := &c.lifecycle
+
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+
+	l.init()
+	l.closed = true
+	watchers := l.watchers
+	l.watchers = nil
+	l.mu.Unlock()
+
+	for _, w := range watchers {
+		w.halt()
+	}
+
+	l.cancel()
+
+	for _, w := range watchers {
+		w.wait()
+	}
+
+	l.inflight.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), logoutTimeout)
+	defer cancel()
+
+	err := c.logout(ctx)
+
+	if c.httpClient != nil {
+		c.httpClient.CloseIdleConnections()
+	}
+
+	return err
+}
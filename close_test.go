@@ -0,0 +1,52 @@
+package netgear
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCloseWaitsForResponseBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<Envelope><Body><ResponseCode>000</ResponseCode></Body></Envelope>")
+	}))
+	defer server.Close()
+
+	addr := server.Listener.Addr().(*net.TCPAddr)
+
+	c := NewClient(addr.IP.String(), "admin", "password")
+	c.Port = addr.Port
+
+	resp, err := c.soap(attachedDevAction, map[string]string{"sessionID": c.SessionID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned before the response body was read")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return once the response body was closed")
+	}
+
+	if _, err := c.soap(attachedDevAction, nil); err != ErrClientClosed {
+		t.Errorf("Expected ErrClientClosed, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package netgear
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// cloudEventTypePrefix prefixes the type of every CloudEvent, followed by the
+// kind of event, such as "device.added"
+const cloudEventTypePrefix = "com.evanpurkhiser.netgear."
+
+// CloudEvent is an event in the CloudEvents 1.0 format
+type CloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype,omitempty"`
+	Data            interface{} `json:"data,omitempty"`
+}
+
+// NewCloudEvent constructs a CloudEvent of the given kind, such as
+// "device.added", originating from the router. Data is encoded as JSON.
+func (c *Client) NewCloudEvent(kind, subject string, data interface{}) *CloudEvent {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return &CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              hex.EncodeToString(id),
+		Source:          "netgear://" + c.Host,
+		Type:            cloudEventTypePrefix + kind,
+		Subject:         subject,
+		Time:            time.Now(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// DeviceCloudEvent constructs the CloudEvent for a device change, with the
+// devices MAC address as the subject
+func (c *Client) DeviceCloudEvent(change *ChangedDevice, data interface{}) *CloudEvent {
+	return c.NewCloudEvent("device."+string(change.Change), c.FormatMAC(change.Device.MAC), data)
+}
+
+// TrafficCloudEvent constructs the CloudEvent for a traffic sample
+func (c *Client) TrafficCloudEvent(sample *TrafficSample) *CloudEvent {
+	return c.NewCloudEvent("traffic.sample", "", sample)
+}
+
+// StructuredRequest encodes the event as an HTTP request in structured mode,
+// with the whole event as the JSON body
+func (e *CloudEvent) StructuredRequest(url string) (*http.Request, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/cloudevents+json")
+
+	return req, nil
+}
+
+// BinaryRequest encodes the event as an HTTP request in binary mode, with
+// the event attributes as ce- headers and the data as the body
+func (e *CloudEvent) BinaryRequest(url string) (*http.Request, error) {
+	body, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", e.DataContentType)
+	req.Header.Set("ce-specversion", e.SpecVersion)
+	req.Header.Set("ce-id", e.ID)
+	req.Header.Set("ce-source", e.Source)
+	req.Header.Set("ce-type", e.Type)
+	req.Header.Set("ce-time", e.Time.UTC().Format(time.RFC3339Nano))
+	if e.Subject != "" {
+		req.Header.Set("ce-subject", e.Subject)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.evanpurkhiser.com/netgear/netgearsink"
+)
+
+// changeDigest is the change of an event summarizing the changes held back
+// by a sinks digest or throttle
+const changeDigest = "digest"
+
+// digest summarizes several changes delivered as a single event
+type digest struct {
+	Since   time.Time      `json:"since"`
+	Count   int            `json:"count"`
+	Changes map[string]int `json:"changes"`
+	Events  []event        `json:"events"`
+}
+
+// summary describes the number of each change, such as "3 added, 1 removed"
+func (d *digest) summary() string {
+	changes := make([]string, 0, len(d.Changes))
+	for change := range d.Changes {
+		changes = append(changes, change)
+	}
+	sort.Strings(changes)
+
+	parts := make([]string, 0, len(changes))
+	for _, change := range changes {
+		parts = append(parts, fmt.Sprintf("%d %s", d.Changes[change], change))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// delivery controls when a sink sends the events it accepts. Events are sent
+// immediately unless held back by the digest or throttle, held back events
+// are sent together as a single digest event. Critical changes are always
+// sent immediately.
+type delivery struct {
+	critical map[string]bool
+
+	// digestEvery holds back every non-critical event, sending a digest at
+	// this interval
+	digestEvery time.Duration
+
+	// At most limit events are sent per period, further events are held back
+	// until the period ends
+	limit  int
+	period time.Duration
+
+	periodStart time.Time
+	sent        int
+	held        []event
+}
+
+// parseDelivery parses the delivery options of a sink, a comma separated
+// list of
+//
+//	digest=<interval>         send a digest of changes at the interval
+//	throttle=<count>/<period> send at most count changes per period
+//	critical=<change>|...     changes always sent immediately
+func parseDelivery(options string) (*delivery, error) {
+	d := &delivery{}
+
+	for _, option := range strings.Split(options, ",") {
+		name, value, _ := strings.Cut(option, "=")
+
+		var err error
+
+		switch name {
+		case "digest":
+			d.digestEvery, err = time.ParseDuration(value)
+		case "throttle":
+			count, period, ok := strings.Cut(value, "/")
+			if !ok {
+				return nil, fmt.Errorf("Throttle %q is not formatted as <count>/<period>", value)
+			}
+			if d.limit, err = strconv.Atoi(count); err != nil {
+				break
+			}
+			d.period, err = time.ParseDuration(period)
+		case "critical":
+			d.critical = map[string]bool{}
+			for _, change := range strings.Split(value, "|") {
+				d.critical[change] = true
+			}
+		default:
+			return nil, fmt.Errorf("Unknown sink option %q, expected digest, throttle, or critical", name)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid sink option %q: %s", option, err)
+		}
+	}
+
+	return d, nil
+}
+
+// admit determines if an event is sent now, otherwise it is held for the
+// next digest
+func (d *delivery) admit(e event, now time.Time) bool {
+	if d.critical[e.Change] {
+		return true
+	}
+
+	if d.digestEvery > 0 {
+		d.held = append(d.held, e)
+		return false
+	}
+
+	if d.limit == 0 {
+		return true
+	}
+
+	if now.Sub(d.periodStart) >= d.period {
+		d.periodStart = now
+		d.sent = 0
+	}
+
+	if d.sent < d.limit && len(d.held) == 0 {
+		d.sent++
+		return true
+	}
+
+	d.held = append(d.held, e)
+
+	return false
+}
+
+// flushIn is how long until the held events should be sent, zero when none
+// are held
+func (d *delivery) flushIn(now time.Time) time.Duration {
+	switch {
+	case len(d.held) == 0:
+		return 0
+	case d.digestEvery > 0:
+		return d.digestEvery
+	default:
+		return d.periodStart.Add(d.period).Sub(now)
+	}
+}
+
+// flush collects the held events into a digest event
+func (d *delivery) flush(now time.Time) (event, bool) {
+	if len(d.held) == 0 {
+		return event{}, false
+	}
+
+	summary := &digest{
+		Since:   d.held[0].Time,
+		Count:   len(d.held),
+		Changes: map[string]int{},
+		Events:  d.held,
+	}
+
+	for _, e := range d.held {
+		summary.Changes[e.Change]++
+	}
+
+	d.held = nil
+
+	// The digest counts against the throttle of the new period
+	d.periodStart = now
+	d.sent = 1
+
+	return event{Event: netgearsink.Event{Change: changeDigest, Time: now}, Digest: summary}, true
+}
+
+func (s *sink) run() {
+	var flush <-chan time.Time
+
+	for {
+		select {
+		case e, ok := <-s.queue:
+			if !ok {
+				return
+			}
+
+			if s.delivery == nil || s.delivery.admit(e, time.Now()) {
+				s.send(e)
+			}
+
+			// Schedule the digest when the first event is held
+			if s.delivery != nil && flush == nil {
+				if wait := s.delivery.flushIn(time.Now()); wait > 0 {
+					flush = time.After(wait)
+				}
+			}
+		case <-flush:
+			flush = nil
+
+			if e, ok := s.delivery.flush(time.Now()); ok {
+				s.send(e)
+			}
+		}
+	}
+}
+
+func (s *sink) send(e event) {
+	if err := s.sender.Send(e); err != nil {
+		fmt.Fprintf(os.Stderr, "Sink %s failed: %s\n", s.spec, err)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// encoder formats the events and errors written to stdout. Each is written
+// as a single complete line, so records are never interleaved.
+type encoder interface {
+	Event(w io.Writer, e event) error
+	Error(w io.Writer, at time.Time, err error) error
+}
+
+// parseEncoder looks up the encoder for a format. Only the human readable
+// text format is localized using the messages.
+func parseEncoder(format string, messages catalog) (encoder, error) {
+	switch format {
+	case "text":
+		return textEncoder{messages}, nil
+	case "ndjson":
+		return ndjsonEncoder{}, nil
+	case "logfmt":
+		return logfmtEncoder{}, nil
+	}
+
+	return nil, fmt.Errorf("Unknown format %q, expected text, ndjson, or logfmt", format)
+}
+
+// textEncoder writes human readable lines
+type textEncoder struct {
+	messages catalog
+}
+
+func (t textEncoder) Event(w io.Writer, e event) error {
+	if e.Digest != nil {
+		_, err := fmt.Fprintf(w, "%s: %d (%s)\n", t.messages.change(e.Change), e.Digest.Count, e.Digest.summary())
+		return err
+	}
+
+	if e.Band != "" {
+		_, err := fmt.Fprintf(w, "%s: %s\n", t.messages.change(e.Change), e.Band)
+		return err
+	}
+
+	mac := e.MAC
+	if e.Randomized {
+		mac += " [" + t.messages[msgRandomized] + "]"
+	}
+
+	// Metadata is omitted from events under -privacy-key, see
+	// netgearsink.NewEvent
+	name := e.Name
+	if e.Meta != nil && e.Meta.Owner != "" {
+		name += ", " + e.Meta.Owner
+	}
+	if e.Meta != nil && len(e.Meta.Tags) > 0 {
+		name += " [" + strings.Join(e.Meta.Tags, ", ") + "]"
+	}
+
+	change := t.messages.change(e.Change)
+	if e.Change == string(netgear.DeviceRemoved) && e.State == string(netgear.StateSleeping) {
+		change += ", " + t.messages[msgSleeping]
+	}
+
+	_, err := fmt.Fprintf(w, "%s: %s (%s)\n", change, mac, name)
+	return err
+}
+
+func (t textEncoder) Error(w io.Writer, at time.Time, err error) error {
+	_, werr := fmt.Fprintf(w, "%s: %s\n", t.messages[msgQueryFailed], t.messages.explain(err))
+	return werr
+}
+
+// ndjsonEncoder writes each record as a line of JSON
+type ndjsonEncoder struct{}
+
+func (ndjsonEncoder) Event(w io.Writer, e event) error {
+	return writeJSONLine(w, e)
+}
+
+func (ndjsonEncoder) Error(w io.Writer, at time.Time, err error) error {
+	return writeJSONLine(w, struct {
+		Time  time.Time `json:"time"`
+		Error string    `json:"error"`
+	}{at, err.Error()})
+}
+
+func writeJSONLine(w io.Writer, v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+// logfmtEncoder writes each record as a line of key=value pairs
+type logfmtEncoder struct{}
+
+func (logfmtEncoder) Event(w io.Writer, e event) error {
+	l := logfmtLine{}
+	l.add("time", e.Time.Format(time.RFC3339Nano))
+	l.add("change", e.Change)
+
+	if e.Digest != nil {
+		l.add("count", strconv.Itoa(e.Digest.Count))
+		l.add("changes", e.Digest.summary())
+
+		return l.write(w)
+	}
+
+	if e.Band != "" {
+		l.add("band", e.Band)
+
+		return l.write(w)
+	}
+
+	l.add("mac", e.MAC)
+	l.add("randomized", strconv.FormatBool(e.Randomized))
+	l.addOptional("ip", e.IP)
+	l.add("name", e.Name)
+	l.addOptional("state", e.State)
+
+	if e.Meta != nil {
+		l.addOptional("owner", e.Meta.Owner)
+		l.addOptional("tags", strings.Join(e.Meta.Tags, ","))
+		l.addOptional("notes", e.Meta.Notes)
+	}
+
+	return l.write(w)
+}
+
+func (logfmtEncoder) Error(w io.Writer, at time.Time, err error) error {
+	l := logfmtLine{}
+	l.add("time", at.Format(time.RFC3339Nano))
+	l.add("error", err.Error())
+
+	return l.write(w)
+}
+
+type logfmtLine struct {
+	strings.Builder
+}
+
+func (l *logfmtLine) add(key, value string) {
+	if l.Len() > 0 {
+		l.WriteByte(' ')
+	}
+
+	l.WriteString(key)
+	l.WriteByte('=')
+
+	if value == "" || strings.ContainsAny(value, " =") || strconv.Quote(value) != `"`+value+`"` {
+		value = strconv.Quote(value)
+	}
+	l.WriteString(value)
+}
+
+func (l *logfmtLine) addOptional(key, value string) {
+	if value != "" {
+		l.add(key, value)
+	}
+}
+
+func (l *logfmtLine) write(w io.Writer) error {
+	l.WriteByte('\n')
+
+	_, err := io.WriteString(w, l.String())
+	return err
+}
@@ -1,41 +1,230 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"net"
+	"os"
+	"strings"
 	"time"
 
 	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgearsink"
 )
 
 var (
 	host     = flag.String("host", "192.168.1.1", "Your netgear router address")
+	port     = flag.Int("port", 5000, "Your netgear router SOAP port")
 	username = flag.String("username", "admin", "Your netgear router username")
 	password = flag.String("password", "", "Your netgear router password")
+	iface    = flag.String("interface", "", "Network interface to reach the router through")
+	macFmt   = flag.String("mac-format", "colon", "MAC address format: colon, colon-upper, dash, or bare")
+	privKey  = flag.String("privacy-key", "", "Hash MAC addresses with this key and hide device names and metadata")
+	metaPath = flag.String("meta", "", "JSON file of device owners, tags, and notes keyed by MAC address")
+	format   = flag.String("format", "text", "Stdout output format: text, ndjson, or logfmt")
+	identity = flag.String("identity", "mac", "Track devices by mac, hostname, or mac-ssid")
+	locale   = flag.String("locale", "", "Language of the text output: en, de, or es (default from LANG)")
+	msgPath  = flag.String("messages", "", "JSON file of text output messages, overriding the locale")
+	leaseFor = flag.Duration("lease-time", 0, "Router DHCP lease time, classifies removed devices as sleeping until it expires")
+	syslog   = flag.String("syslog", "", "UDP address to receive the router log on, such as :5514, used to learn DHCP leases")
+	quiet    = flag.String("quiet", "", "Comma separated daily windows to not poll during, such as 02:00-05:00")
+	settle   = flag.Duration("quiet-settle", 10*time.Minute, "How long devices missing after a quiet window are assumed attached")
+	guestOff = flag.Duration("guest-shutoff", 0, "Disable the guest network once no devices have been attached to it for this long")
+	pollTime = flag.Duration("poll", 10*time.Second, "How often to poll the router for attached devices")
+
+	trustPath = flag.String("trust-store", "", "Connect using HTTPS, trusting the router certificate on first use and recording it in this file")
 )
 
-var output = map[netgear.DeviceChange]string{
-	netgear.DeviceAdded:   "Device Added",
-	netgear.DeviceRemoved: "Device Removed",
+var sinkFlags sinkList
+
+func init() {
+	flag.Var(&sinkFlags, "sink", "Output sink formatted as kind[:change,...][{option,...}][=target], may be repeated.\nKinds are stdout, webhook, cloudevents, cloudevents-binary, mqtt, and exec (default stdout).\nOptions are digest=<interval>, throttle=<count>/<period>, and critical=<change>|...")
 }
 
-func listener(change *netgear.ChangedDevice, err error) {
-	if err != nil {
-		fmt.Printf("Failed to query for devices: %s\n", err)
-		return
+var identities = map[string]netgear.IdentityFunc{
+	"mac":      netgear.IdentityMAC,
+	"hostname": netgear.IdentityHostname,
+	"mac-ssid": netgear.IdentityMACSSID,
+}
+
+func newListener(client *netgear.Client, enc encoder, sinks []*sink, leases *netgear.LeaseTracker) netgear.DeviceListener {
+	var trackLeases netgear.DeviceListener
+	if leases != nil {
+		trackLeases = leases.Listener()
+	}
+
+	return func(change *netgear.ChangedDevice, err error) {
+		if err != nil {
+			enc.Error(os.Stdout, time.Now(), err)
+			return
+		}
+
+		e := newEvent(client, change)
+
+		if leases != nil {
+			trackLeases(change, nil)
+			e.State = string(leases.State(change.Device.MAC))
+		}
+
+		publish(sinks, e)
 	}
+}
 
-	mac := change.Device.MAC.String()
-	fmt.Printf(output[change.Change]+": %s\n", mac)
+// publish hands the event to each sink accepting it
+func publish(sinks []*sink, e event) {
+	for _, s := range sinks {
+		if s.accepts(e) {
+			s.enqueue(e)
+		}
+	}
+}
+
+// newLeaseTracker tracks DHCP leases, publishing a gone event once the lease
+// of a removed device expires
+func newLeaseTracker(client *netgear.Client, sinks []*sink) *netgear.LeaseTracker {
+	leases := netgear.NewLeaseTracker(*leaseFor)
+	leases.OnStateChange = func(mac net.HardwareAddr, state netgear.DeviceState) {
+		if state != netgear.StateGone {
+			return
+		}
+
+		publish(sinks, event{
+			Event: netgearsink.Event{
+				MAC:        client.FormatMAC(mac),
+				Randomized: netgear.AttachedDevice{MAC: mac}.IsRandomized(),
+				Change:     changeGone,
+				Time:       time.Now(),
+			},
+			State: string(state),
+		})
+	}
+
+	return leases
+}
+
+// guestShutoffNotifier publishes an event when an idle guest network is
+// disabled
+func guestShutoffNotifier(sinks []*sink) func(netgear.Band, error) {
+	return func(band netgear.Band, err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to disable the %s guest network: %s\n", band, err)
+			return
+		}
+
+		publish(sinks, event{
+			Event: netgearsink.Event{Change: changeGuestDisabled, Time: time.Now()},
+			Band:  string(band),
+		})
+	}
 }
 
 func main() {
 	flag.Parse()
 
-	client := netgear.NewClient(*host, *username, *password)
+	macFormat, err := netgear.ParseMACFormat(*macFmt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(2)
+	}
+
+	messages, err := loadCatalog(*locale, *msgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(2)
+	}
+
+	enc, err := parseEncoder(*format, messages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(2)
+	}
+
+	identityFn, ok := identities[*identity]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown identity %q, expected mac, hostname, or mac-ssid\n", *identity)
+		os.Exit(2)
+	}
+
+	opts := []netgear.ClientOption{netgear.WithMACFormat(macFormat)}
+	if *iface != "" {
+		opts = append(opts, netgear.WithInterface(*iface))
+	}
+	if *trustPath != "" {
+		opts = append(opts, netgear.WithTrustOnFirstUse(&netgear.FileTrustStore{Path: *trustPath}))
+	}
+	if *privKey != "" {
+		opts = append(opts, netgear.WithPrivacy(&netgear.Privacy{Key: []byte(*privKey)}))
+	}
+
+	client := netgear.NewClient(*host, *username, *password, opts...)
+	client.Port = *port
+
+	if len(sinkFlags) == 0 {
+		sinkFlags = sinkList{"stdout"}
+	}
+
+	sinks := make([]*sink, 0, len(sinkFlags))
+	for _, spec := range sinkFlags {
+		s, err := parseSink(client, enc, spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(2)
+		}
+
+		go s.run()
+		sinks = append(sinks, s)
+	}
+
+	meta := map[string]netgear.DeviceMeta{}
+	if *metaPath != "" {
+		contents, err := os.ReadFile(*metaPath)
+		if err == nil {
+			err = json.Unmarshal(contents, &meta)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to load device metadata: %s\n", err)
+			os.Exit(2)
+		}
+	}
+
+	watchOpts := []netgear.WatchOption{netgear.WithDeviceMeta(meta), netgear.WithIdentity(identityFn)}
+
+	// The SSID is only reported by the detailed device list
+	if *identity == "mac-ssid" || *guestOff > 0 {
+		watchOpts = append(watchOpts, netgear.WithDetailedEvery(1))
+	}
+
+	if *quiet != "" {
+		windows := []netgear.QuietWindow{}
+		for _, value := range strings.Split(*quiet, ",") {
+			window, err := netgear.ParseQuietWindow(value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				os.Exit(2)
+			}
+			windows = append(windows, window)
+		}
+		watchOpts = append(watchOpts, netgear.WithQuietWindows(*settle, windows...))
+	}
+
+	if *guestOff > 0 {
+		watchOpts = append(watchOpts, netgear.WithGuestShutoff(*guestOff, guestShutoffNotifier(sinks)))
+	}
+
+	var leases *netgear.LeaseTracker
+	if *leaseFor > 0 || *syslog != "" {
+		leases = newLeaseTracker(client, sinks)
+	}
+
+	if *syslog != "" {
+		if err := receiveSyslog(*syslog, leases.AddLog); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to receive syslog: %s\n", err)
+			os.Exit(2)
+		}
+	}
 
-	pollTime := time.Second * 10
-	client.OnDeviceChanged(pollTime, listener)
+	client.Watch(*pollTime, newListener(client, enc, sinks, leases), watchOpts...)
 
 	<-make(chan bool)
 }
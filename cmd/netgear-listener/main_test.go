@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+const waitTimeout = 5 * time.Second
+
+// binary is the path of the netgear-listener command built for the tests
+var binary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "netgear-listener")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	binary = filepath.Join(dir, "netgear-listener")
+
+	build := exec.Command("go", "build", "-o", binary, ".")
+	build.Stderr = os.Stderr
+
+	if err := build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to build the netgear-listener command: %s\n", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// command constructs the netgear-listener command polling the mock router,
+// with the flags connecting to it preceding args
+func command(server *netgeartest.Server, args ...string) *exec.Cmd {
+	addr := server.Listener.Addr().(*net.TCPAddr)
+	flags := []string{
+		"-host", addr.IP.String(),
+		"-port", strconv.Itoa(addr.Port),
+		"-username", server.Username,
+		"-password", server.Password,
+		"-poll", "20ms",
+	}
+
+	return exec.Command(binary, append(flags, args...)...)
+}
+
+// listen starts the netgear-listener command, returning a function reading
+// the next line written to stdout. The command is killed once the test
+// finishes.
+func listen(t *testing.T, server *netgeartest.Server, args ...string) func() string {
+	t.Helper()
+
+	cmd := command(server, args...)
+	cmd.Stderr = os.Stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	return func() string {
+		t.Helper()
+
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("Expected a line, the listener exited")
+			}
+			return line
+		case <-time.After(waitTimeout):
+			t.Fatal("Timed out waiting for a line")
+		}
+
+		return ""
+	}
+}
+
+// parseLogfmt splits a logfmt line into its keys and values
+func parseLogfmt(t *testing.T, line string) map[string]string {
+	t.Helper()
+
+	pairs := map[string]string{}
+
+	for line != "" {
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok {
+			t.Fatalf("Expected key=value pairs, got %q", line)
+		}
+
+		value := rest
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				t.Fatalf("Invalid quoted value in %q: %s", line, err)
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else {
+			value, rest, _ = strings.Cut(rest, " ")
+		}
+
+		pairs[key] = value
+		line = strings.TrimPrefix(rest, " ")
+	}
+
+	return pairs
+}
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		check  func(t *testing.T, failed, added string)
+	}{
+		{"text", func(t *testing.T, failed, added string) {
+			if !strings.HasPrefix(failed, "Failed to query for devices: ") || !strings.HasSuffix(failed, "the router rejected the username or password") {
+				t.Errorf("Expected the failure explained, got %q", failed)
+			}
+			if added != "Device Added: a4:83:e7:00:00:01 (laptop)" {
+				t.Errorf("Expected the laptop added, got %q", added)
+			}
+		}},
+		{"ndjson", func(t *testing.T, failed, added string) {
+			e := map[string]any{}
+			if err := json.Unmarshal([]byte(failed), &e); err != nil || e["error"] == "" || e["time"] == nil || len(e) != 2 {
+				t.Errorf("Expected a JSON error with its time, got %q", failed)
+			}
+
+			e = map[string]any{}
+			if err := json.Unmarshal([]byte(added), &e); err != nil {
+				t.Fatalf("Expected a JSON event, got %q: %s", added, err)
+			}
+
+			expected := map[string]any{"change": "added", "mac": "a4:83:e7:00:00:01", "ip": "192.168.1.2", "name": "laptop", "randomized": false}
+			for key, value := range expected {
+				if e[key] != value {
+					t.Errorf("Expected %s %v, got %q", key, value, added)
+				}
+			}
+			if _, err := time.Parse(time.RFC3339Nano, fmt.Sprint(e["time"])); err != nil {
+				t.Errorf("Expected the event time, got %q", added)
+			}
+		}},
+		{"logfmt", func(t *testing.T, failed, added string) {
+			if e := parseLogfmt(t, failed); e["error"] == "" || e["time"] == "" || len(e) != 2 {
+				t.Errorf("Expected an error with its time, got %q", failed)
+			}
+
+			e := parseLogfmt(t, added)
+			expected := map[string]string{"change": "added", "mac": "a4:83:e7:00:00:01", "ip": "192.168.1.2", "name": "laptop", "randomized": "false"}
+			for key, value := range expected {
+				if e[key] != value {
+					t.Errorf("Expected %s=%s, got %q", key, value, added)
+				}
+			}
+			if _, err := time.Parse(time.RFC3339Nano, e["time"]); err != nil {
+				t.Errorf("Expected the event time, got %q", added)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			server := netgeartest.Start(t)
+			server.SetDevices(netgeartest.Device(t, "a4:83:e7:00:00:01", "192.168.1.2", "laptop"))
+
+			// The first poll fails to login, the next reports the laptop
+			server.FailAuth(1)
+
+			next := listen(t, server, "-format", tt.format, "-locale", "en")
+			failed := next()
+			tt.check(t, failed, next())
+		})
+	}
+}
+
+func TestCloudEvents(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "a4:83:e7:00:00:01", "192.168.1.2", "laptop"))
+
+	type request struct {
+		contentType string
+		body        []byte
+	}
+
+	requests := make(chan request, 16)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		requests <- request{r.Header.Get("Content-Type"), body}
+	}))
+	t.Cleanup(receiver.Close)
+
+	listen(t, server, "-sink", "cloudevents="+receiver.URL)
+
+	var req request
+	select {
+	case req = <-requests:
+	case <-time.After(waitTimeout):
+		t.Fatal("Timed out waiting for a CloudEvent")
+	}
+
+	if req.contentType != "application/cloudevents+json" {
+		t.Errorf("Expected a structured CloudEvent, got content type %q", req.contentType)
+	}
+
+	e := struct {
+		SpecVersion string         `json:"specversion"`
+		ID          string         `json:"id"`
+		Source      string         `json:"source"`
+		Type        string         `json:"type"`
+		Subject     string         `json:"subject"`
+		Time        time.Time      `json:"time"`
+		Data        map[string]any `json:"data"`
+	}{}
+
+	if err := json.Unmarshal(req.body, &e); err != nil {
+		t.Fatalf("Expected a JSON CloudEvent, got %q: %s", req.body, err)
+	}
+
+	if e.SpecVersion != "1.0" || e.ID == "" || e.Time.IsZero() {
+		t.Errorf("Expected the required CloudEvent attributes, got %s", req.body)
+	}
+	if e.Type != "com.evanpurkhiser.netgear.device.added" || e.Subject != "a4:83:e7:00:00:01" || !strings.HasPrefix(e.Source, "netgear://") {
+		t.Errorf("Expected the laptop added, got %s", req.body)
+	}
+	if e.Data["mac"] != "a4:83:e7:00:00:01" || e.Data["name"] != "laptop" {
+		t.Errorf("Expected the event as the data, got %s", req.body)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	tests := []struct {
+		args   []string
+		stderr string
+	}{
+		{[]string{"-format", "xml"}, `Unknown format "xml"`},
+		{[]string{"-identity", "serial"}, `Unknown identity "serial"`},
+		{[]string{"-sink", "pager=alice"}, `Unknown sink "pager"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			cmd := command(server, tt.args...)
+
+			out, err := cmd.CombinedOutput()
+			exitErr := &exec.ExitError{}
+			if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+				t.Fatalf("Expected exit code 2, got %v:\n%s", err, out)
+			}
+
+			if !strings.Contains(string(out), tt.stderr) {
+				t.Errorf("Expected %q, got:\n%s", tt.stderr, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// Message keys of the human readable text output. Device change phrases are
+// keyed by the change, such as "added".
+const (
+	msgRandomized  = "randomized"
+	msgSleeping    = "sleeping"
+	msgQueryFailed = "query_failed"
+
+	// Explanations of router response codes are keyed as error.<code>
+	msgErrorPrefix = "error."
+)
+
+// catalog maps message keys to the text displayed for them
+type catalog map[string]string
+
+// catalogs are the built in locales. Messages missing from a locale fall
+// back to English.
+var catalogs = map[string]catalog{
+	"en": {
+		"added":          "Device Added",
+		"removed":        "Device Removed",
+		"updated":        "Device Updated",
+		"seen":           "Device Seen",
+		"gone":           "Device Gone",
+		"digest":         "Changes",
+		"guest-disabled": "Guest Network Disabled",
+		msgRandomized:    "randomized",
+		msgSleeping:      "likely asleep",
+		msgQueryFailed:   "Failed to query for devices",
+		"error.401":      "the router rejected the username or password",
+		"error.501":      "the router does not support this request",
+	},
+	"de": {
+		"added":          "Gerät verbunden",
+		"removed":        "Gerät getrennt",
+		"updated":        "Gerät geändert",
+		"seen":           "Gerät gesehen",
+		"gone":           "Gerät abwesend",
+		"digest":         "Änderungen",
+		"guest-disabled": "Gastnetz deaktiviert",
+		msgRandomized:    "zufällig",
+		msgSleeping:      "vermutlich im Ruhezustand",
+		msgQueryFailed:   "Geräte konnten nicht abgefragt werden",
+		"error.401":      "der Router hat Benutzername oder Passwort abgelehnt",
+		"error.501":      "der Router unterstützt diese Anfrage nicht",
+	},
+	"es": {
+		"added":          "Dispositivo conectado",
+		"removed":        "Dispositivo desconectado",
+		"updated":        "Dispositivo actualizado",
+		"seen":           "Dispositivo visto",
+		"gone":           "Dispositivo ausente",
+		"digest":         "Cambios",
+		"guest-disabled": "Red de invitados desactivada",
+		msgRandomized:    "aleatoria",
+		msgSleeping:      "probablemente en reposo",
+		msgQueryFailed:   "No se pudieron consultar los dispositivos",
+		"error.401":      "el router rechazó el usuario o la contraseña",
+		"error.501":      "el router no admite esta solicitud",
+	},
+}
+
+// loadCatalog builds the catalog for a locale, such as "de" or "de_DE.UTF-8".
+// The locale is taken from the LANG environment variable when empty. Messages
+// from the JSON file at path, when given, take precedence, which allows
+// adding locales without rebuilding. The file maps message keys to text:
+//
+//	{"added": "Appareil connecté", "removed": "Appareil déconnecté"}
+func loadCatalog(locale, path string) (catalog, error) {
+	if locale == "" {
+		locale = os.Getenv("LANG")
+	}
+
+	// Reduce locales such as de_DE.UTF-8 to the language
+	language := ""
+	if parts := strings.FieldsFunc(locale, isLocaleSeparator); len(parts) > 0 {
+		language = strings.ToLower(parts[0])
+	}
+
+	messages := catalog{}
+	for key, text := range catalogs["en"] {
+		messages[key] = text
+	}
+	for key, text := range catalogs[language] {
+		messages[key] = text
+	}
+
+	if path == "" {
+		return messages, nil
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := catalog{}
+	if err := json.Unmarshal(contents, &overrides); err != nil {
+		return nil, fmt.Errorf("Unable to parse messages %s: %s", path, err)
+	}
+
+	for key, text := range overrides {
+		messages[key] = text
+	}
+
+	return messages, nil
+}
+
+func isLocaleSeparator(r rune) bool {
+	return r == '_' || r == '-' || r == '.'
+}
+
+// change is the phrase for a device change
+func (c catalog) change(change string) string {
+	if text, ok := c[change]; ok {
+		return text
+	}
+
+	return change
+}
+
+// explain describes an error, including an explanation of the router response
+// code when one is known
+func (c catalog) explain(err error) string {
+	respErr := &netgear.ResponseError{}
+	if !errors.As(err, &respErr) {
+		return err.Error()
+	}
+
+	explanation, ok := c[msgErrorPrefix+strconv.Itoa(respErr.Code)]
+	if !ok {
+		return err.Error()
+	}
+
+	return fmt.Sprintf("%s, %s", err, explanation)
+}
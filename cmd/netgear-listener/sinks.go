@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgearsink"
+)
+
+// sinkQueueSize is the number of changes buffered for each sink. Changes are
+// dropped for a sink that falls this far behind, so one slow or broken sink
+// does not hold up the others.
+const sinkQueueSize = 64
+
+// sender delivers a device change to a single destination
+type sender interface {
+	Send(event event) error
+}
+
+type event struct {
+	netgearsink.Event
+
+	// State is online, sleeping, or gone when DHCP leases are tracked
+	State string `json:"state,omitempty"`
+
+	// Band is the wireless band of guest network changes
+	Band string `json:"band,omitempty"`
+
+	// Digest is set for digest changes, summarizing the changes held back
+	// by a sinks digest or throttle
+	Digest *digest `json:"digest,omitempty"`
+}
+
+// changeGone is the change published when the DHCP lease of a removed device
+// expires
+const changeGone = "gone"
+
+// changeGuestDisabled is the change published when an idle guest network is
+// disabled
+const changeGuestDisabled = "guest-disabled"
+
+func newEvent(client *netgear.Client, change *netgear.ChangedDevice) event {
+	return event{Event: netgearsink.NewEvent(client, change)}
+}
+
+// sink is a configured output, receiving the changes matching its filter on
+// its own goroutine
+type sink struct {
+	spec     string
+	changes  map[string]bool
+	delivery *delivery
+	sender   sender
+	queue    chan event
+}
+
+// parseSink parses a sink flag, formatted as
+// kind[:change,...][{option,...}][=target], see parseDelivery for the options.
+// For example
+//
+//	stdout
+//	webhook:added=http://localhost:8080/hook
+//	webhook{digest=15m,critical=added}=http://localhost:8080/hook
+//	cloudevents=http://localhost:8080/events
+//	mqtt=tcp://localhost:1883/netgear/presence
+//	exec:added,removed{throttle=5/1m}=/usr/local/bin/notify
+func parseSink(client *netgear.Client, enc encoder, spec string) (*sink, error) {
+	kind, target, _ := strings.Cut(spec, "=")
+
+	s := &sink{spec: spec, queue: make(chan event, sinkQueueSize)}
+
+	// Options may contain an =, so the target starts after the options
+	if open := strings.Index(spec, "{"); open >= 0 && open < len(kind) {
+		end := strings.Index(spec, "}")
+		if end < open {
+			return nil, fmt.Errorf("Sink %q has unterminated options", spec)
+		}
+
+		var err error
+		if s.delivery, err = parseDelivery(spec[open+1 : end]); err != nil {
+			return nil, err
+		}
+
+		kind = spec[:open]
+		target = strings.TrimPrefix(spec[end+1:], "=")
+	}
+
+	kind, filter, _ := strings.Cut(kind, ":")
+
+	if filter != "" {
+		s.changes = map[string]bool{}
+		for _, change := range strings.Split(filter, ",") {
+			s.changes[change] = true
+		}
+	}
+
+	var err error
+
+	switch kind {
+	case "stdout":
+		s.sender = stdoutSender{encoder: enc}
+	case "webhook":
+		s.sender = webhookSender{&netgearsink.Webhook{Client: client, URL: target, Format: netgearsink.FormatJSON}}
+	case "cloudevents":
+		s.sender = webhookSender{&netgearsink.Webhook{Client: client, URL: target, Format: netgearsink.FormatCloudEvents}}
+	case "cloudevents-binary":
+		s.sender = webhookSender{&netgearsink.Webhook{Client: client, URL: target, Format: netgearsink.FormatCloudEventsBinary}}
+	case "mqtt":
+		s.sender, err = newMQTTSender(target)
+	case "exec":
+		s.sender = &execSender{command: target}
+	default:
+		return nil, fmt.Errorf("Unknown sink %q, expected stdout, webhook, cloudevents, cloudevents-binary, mqtt, or exec", kind)
+	}
+
+	if kind != "stdout" && target == "" {
+		return nil, fmt.Errorf("Sink %q is missing a target", spec)
+	}
+
+	return s, err
+}
+
+func (s *sink) accepts(e event) bool {
+	return s.changes == nil || s.changes[e.Change]
+}
+
+// enqueue hands the event to the sink without blocking
+func (s *sink) enqueue(e event) {
+	select {
+	case s.queue <- e:
+	default:
+		fmt.Fprintf(os.Stderr, "Sink %s is falling behind, dropped %s change for %s\n", s.spec, e.Change, e.MAC)
+	}
+}
+
+// sinkList collects repeated -sink flags
+type sinkList []string
+
+func (l *sinkList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *sinkList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// stdoutSender writes each change to stdout using the configured encoder
+type stdoutSender struct {
+	encoder encoder
+}
+
+func (s stdoutSender) Send(e event) error {
+	return s.encoder.Event(os.Stdout, e)
+}
+
+// webhookSender posts each change to a URL
+type webhookSender struct {
+	hook *netgearsink.Webhook
+}
+
+func (s webhookSender) Send(e event) error {
+	return s.hook.Post(e.Change, e.MAC, e)
+}
+
+// mqttSender publishes a retained presence state per device to
+// <prefix>/<mac>/state, along with each change to <prefix>/events
+type mqttSender struct {
+	mqtt *netgearsink.MQTT
+}
+
+// newMQTTSender connects to the broker of a target formatted as
+// tcp://[user:password@]host:port[/prefix][?client_id=id]. A unique client
+// ID is generated for each sink unless one is given.
+func newMQTTSender(target string) (*mqttSender, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &netgearsink.MQTT{
+		Broker:      u.Scheme + "://" + u.Host,
+		ClientID:    u.Query().Get("client_id"),
+		TopicPrefix: strings.Trim(u.Path, "/"),
+	}
+
+	if u.User != nil {
+		m.Username = u.User.Username()
+		m.Password, _ = u.User.Password()
+	}
+
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+
+	return &mqttSender{mqtt: m}, nil
+}
+
+func (s *mqttSender) Send(e event) error {
+	// The retained state of each device in a digest is still updated
+	states := []event{e}
+	if e.Digest != nil {
+		states = e.Digest.Events
+	}
+
+	for _, e := range states {
+		if e.MAC == "" {
+			continue
+		}
+
+		home := e.Change != string(netgear.DeviceRemoved) && e.Change != changeGone
+		if err := s.mqtt.PublishState(e.MAC, home); err != nil {
+			return err
+		}
+	}
+
+	return s.mqtt.PublishEvent(e)
+}
+
+// execSender runs a command for each change. The change is passed as JSON on
+// stdin and through NETGEAR_* environment variables.
+type execSender struct {
+	command string
+}
+
+func (s *execSender) Send(e event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(s.command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"NETGEAR_CHANGE="+e.Change,
+		"NETGEAR_MAC="+e.MAC,
+		"NETGEAR_IP="+e.IP,
+		"NETGEAR_NAME="+e.Name,
+		"NETGEAR_STATE="+e.State,
+		"NETGEAR_BAND="+e.Band,
+	)
+
+	return cmd.Run()
+}
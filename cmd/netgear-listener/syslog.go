@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// receiveSyslog listens for the router log forwarded over UDP syslog, passing
+// each entry to fn. The syslog header is discarded, the router log entry
+// starts at the first bracketed kind.
+func receiveSyslog(addr string, fn func(netgear.LogEntry)) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		buf := make([]byte, 2048)
+
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to read syslog: %s\n", err)
+				return
+			}
+
+			line := string(buf[:n])
+			if i := strings.Index(line, "["); i >= 0 {
+				line = line[i:]
+			}
+
+			if entry, ok := netgear.ParseLogEntry(line, time.Local); ok {
+				fn(entry)
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// authTestInterval is the minimum time between login attempts made by the
+// auth test command. Some routers lock the admin account after several
+// failed logins in quick succession.
+const authTestInterval = time.Minute
+
+// Meaning of the response codes returned when logging in
+var loginResponseCodes = map[int]string{
+	401: "The credentials were rejected by the router",
+	501: "The router does not support this login method",
+}
+
+func authCommand(args []string) (action, error) {
+	if len(args) == 0 || args[0] != "test" {
+		return nil, fmt.Errorf("Usage: auth test [-force]")
+	}
+
+	flags := flag.NewFlagSet("auth test", flag.ContinueOnError)
+	force := flags.Bool("force", false, "Skip the rate limit between login attempts")
+	if err := flags.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	return func(client *netgear.Client) error { return authTest(client, *force) }, nil
+}
+
+func authTest(client *netgear.Client, force bool) error {
+	lastAttempt := lastAuthAttempt(client.Host)
+	if wait := authTestInterval - time.Since(lastAttempt); wait > 0 && !force {
+		return fmt.Errorf("Last login attempt was %s ago, wait %s before trying again", time.Since(lastAttempt).Round(time.Second), wait.Round(time.Second))
+	}
+
+	fmt.Printf("Router:    %s:%d\n", client.Host, client.Port)
+	fmt.Printf("Username:  %s\n\n", client.Username)
+
+	fmt.Println("Warning: routers may lock the admin account after repeated failed")
+	fmt.Println("logins. Only a single login attempt will be made.")
+	fmt.Println()
+
+	recordAuthAttempt(client.Host)
+
+	err := client.Login()
+
+	if mode := authMode(client, err); mode != "" {
+		fmt.Printf("Auth mode: %s\n", mode)
+	}
+
+	respErr := &netgear.ResponseError{}
+	switch {
+	case err == nil:
+		fmt.Println("Result:    OK, credentials accepted")
+		return nil
+	case errors.As(err, &respErr):
+		meaning, ok := loginResponseCodes[respErr.Code]
+		if !ok {
+			meaning = "Unknown response code"
+		}
+
+		fmt.Printf("Result:    Response code %03d, %s\n", respErr.Code, meaning)
+	default:
+		fmt.Printf("Result:    Unable to reach the router, %s\n", err)
+	}
+
+	return fmt.Errorf("Authentication test failed: %w", err)
+}
+
+// authMode describes how the router answered the login attempt, empty when
+// it did not answer at all
+func authMode(client *netgear.Client, err error) string {
+	respErr := &netgear.ResponseError{}
+	if err != nil && !errors.As(err, &respErr) {
+		return ""
+	}
+
+	transport := "HTTP"
+	if *trustPath != "" {
+		transport = "HTTPS"
+	}
+
+	if respErr.Code == 501 {
+		return fmt.Sprintf("Unknown, the router does not implement SOAP login over %s on port %d", transport, client.Port)
+	}
+
+	return fmt.Sprintf("SOAP login over %s on port %d", transport, client.Port)
+}
+
+func authAttemptFile(host string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(cacheDir, "netgear", "auth-attempt-"+host), nil
+}
+
+func lastAuthAttempt(host string) time.Time {
+	path, err := authAttemptFile(host)
+	if err != nil {
+		return time.Time{}
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	unix, err := strconv.ParseInt(strings.TrimSpace(string(contents)), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(unix, 0)
+}
+
+func recordAuthAttempt(host string) {
+	path, err := authAttemptFile(host)
+	if err != nil {
+		return
+	}
+
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	os.WriteFile(path, []byte(strconv.FormatInt(time.Now().Unix(), 10)), 0o644)
+}
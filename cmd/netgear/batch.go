@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// configCommands change the router configuration. A batch containing any of
+// these runs within a single configuration transaction.
+var configCommands = map[string]bool{
+	"pause":  true,
+	"resume": true,
+}
+
+// The batch command refers back to the commands map to run each line
+func init() {
+	commands["batch"] = batchCommand
+}
+
+// batchLine is a single command read from a batch
+type batchLine struct {
+	number int
+	args   []string
+	run    action
+}
+
+func batchCommand(args []string) (action, error) {
+	flags := flag.NewFlagSet("batch", flag.ContinueOnError)
+	keepGoing := flags.Bool("keep-going", false, "Run the remaining commands after one fails, reporting every failure")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() != 1 {
+		return nil, fmt.Errorf("Usage: batch [-keep-going] <file>, use - to read commands from stdin")
+	}
+
+	input := io.Reader(os.Stdin)
+	if path := flags.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	lines, err := readBatch(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(client *netgear.Client) error { return runBatch(client, lines, *keepGoing) }, nil
+}
+
+func runBatch(client *netgear.Client, lines []batchLine, keepGoing bool) error {
+	transaction := false
+	for _, line := range lines {
+		transaction = transaction || configCommands[line.args[0]]
+	}
+
+	if err := login(client); err != nil {
+		return err
+	}
+
+	run := func() error {
+		result := &netgear.MultiError{}
+
+		for _, line := range lines {
+			err := line.run(client)
+			if err != nil && !keepGoing {
+				return fmt.Errorf("Line %d: %s", line.number, err)
+			}
+			result.Add(line.target(client), err)
+		}
+
+		return result.ErrorOrNil()
+	}
+
+	if transaction {
+		return client.Configure(context.Background(), func(ctx context.Context) error {
+			configContext = ctx
+			defer func() { configContext = context.Background() }()
+
+			return run()
+		})
+	}
+
+	return run()
+}
+
+// target describes the command of the line, including the device it applies
+// to when the first argument is a MAC address
+func (l batchLine) target(client *netgear.Client) netgear.Target {
+	target := netgear.Target{
+		Router: client.Host,
+		Action: fmt.Sprintf("line %d: %s", l.number, l.args[0]),
+	}
+
+	if len(l.args) > 1 {
+		target.Device, _ = net.ParseMAC(l.args[1])
+	}
+
+	return target
+}
+
+// readBatch reads one command per line, ignoring blank lines and lines
+// starting with #. Every line is parsed, including the flags and arguments of
+// its command, so an invalid line is reported before any are run.
+func readBatch(input io.Reader) ([]batchLine, error) {
+	lines := []batchLine{}
+	scanner := bufio.NewScanner(input)
+
+	for number := 1; scanner.Scan(); number++ {
+		args := strings.Fields(scanner.Text())
+		if len(args) == 0 || strings.HasPrefix(args[0], "#") {
+			continue
+		}
+
+		cmd, ok := commands[args[0]]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("Line %d: Unknown command %q", number, args[0])
+		case args[0] == "batch" || args[0] == "auth":
+			return nil, fmt.Errorf("Line %d: The %s command cannot be used in a batch", number, args[0])
+		}
+
+		run, err := cmd(args[1:])
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", number, err)
+		}
+
+		lines = append(lines, batchLine{number, args, run})
+	}
+
+	return lines, scanner.Err()
+}
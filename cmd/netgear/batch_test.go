@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+// writeBatch writes the lines of a batch to a file, returning its path
+func writeBatch(t *testing.T, lines ...string) string {
+	path := filepath.Join(t.TempDir(), "batch")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestBatch(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(
+		netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "laptop"),
+		netgeartest.Device(t, "aa:bb:cc:00:00:02", "192.168.1.3", "phone"),
+	)
+
+	path := writeBatch(t,
+		"# Pause the laptop, then look it up",
+		"pause aa:bb:cc:00:00:01",
+		"",
+		"get device.aa:bb:cc:00:00:01.ip",
+		"pause aa:bb:cc:00:00:02",
+		"resume aa:bb:cc:00:00:02",
+	)
+
+	r := run(t, server, "batch", path)
+	expectExit(t, r, 0)
+
+	if r.stdout != "192.168.1.2\n" {
+		t.Errorf("Expected the output of the get line, got %q", r.stdout)
+	}
+
+	if !server.Blocked(netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")) {
+		t.Error("Expected the laptop to be paused")
+	}
+	if server.Blocked(netgeartest.MustMAC(t, "aa:bb:cc:00:00:02")) {
+		t.Error("Expected the phone to be resumed")
+	}
+}
+
+func TestBatchInvalidLine(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	tests := []struct {
+		line string
+		err  string
+	}{
+		{"reboot", `Line 2: Unknown command "reboot"`},
+		{"auth test", "Line 2: The auth command cannot be used in a batch"},
+		{"pause laptop", "Line 2: address laptop: invalid MAC address"},
+		{"get lan.ip", `Line 2: Unknown path "lan.ip"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			path := writeBatch(t, "pause aa:bb:cc:00:00:01", tt.line)
+
+			// The whole batch is rejected before connecting to the router
+			r := run(t, server, "batch", path)
+			expectExit(t, r, 2)
+
+			if !strings.HasPrefix(r.stderr, tt.err) {
+				t.Errorf("Expected %q, got %q", tt.err, r.stderr)
+			}
+
+			if server.Blocked(netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")) {
+				t.Error("Expected the valid line not to run")
+			}
+		})
+	}
+}
+
+func TestBatchFailure(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "laptop"))
+
+	path := writeBatch(t,
+		"get device.aa:bb:cc:00:00:02.ip",
+		"pause aa:bb:cc:00:00:01",
+	)
+
+	// Without -keep-going the batch stops at the first failure
+	r := run(t, server, "batch", path)
+	expectExit(t, r, 1)
+
+	if !strings.HasPrefix(r.stderr, "Line 1: Device aa:bb:cc:00:00:02 is not attached") {
+		t.Errorf("Expected the first line to fail, got %q", r.stderr)
+	}
+
+	if server.Blocked(netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")) {
+		t.Error("Expected the batch to stop before pausing the laptop")
+	}
+}
+
+func TestBatchKeepGoing(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "laptop"))
+
+	path := writeBatch(t,
+		"get device.aa:bb:cc:00:00:02.ip",
+		"pause aa:bb:cc:00:00:01",
+	)
+
+	r := run(t, server, "-errors", "json", "batch", "-keep-going", path)
+	expectExit(t, r, 1)
+
+	if !server.Blocked(netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")) {
+		t.Error("Expected the remaining lines to run")
+	}
+
+	e := decodeError(t, r.stderr)
+	failures, _ := e["failures"].([]any)
+	if e["class"] != classPartial || len(failures) != 1 {
+		t.Fatalf("Expected a partial failure of one line, got %s", r.stderr)
+	}
+
+	if message, _ := failures[0].(map[string]any)["message"].(string); !strings.Contains(message, "line 1: get") {
+		t.Errorf("Expected the failure to name its line, got %q", message)
+	}
+}
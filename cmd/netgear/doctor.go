@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// doctorPorts are the SOAP ports used by netgear firmware, newer firmware
+// uses 5000 while older firmware uses 80
+var doctorPorts = []int{5000, 80}
+
+// dialTimeout bounds each connection attempt when checking reachability
+const dialTimeout = 3 * time.Second
+
+// maxClockSkew is the clock skew beyond which schedules are likely to
+// misbehave
+const maxClockSkew = 2 * time.Minute
+
+// doctor runs checks against the router, printing a finding for each
+type doctor struct {
+	client *netgear.Client
+	failed bool
+}
+
+func (d *doctor) ok(check, format string, args ...interface{}) {
+	d.report("OK", check, format, args...)
+}
+
+func (d *doctor) info(check, format string, args ...interface{}) {
+	d.report("INFO", check, format, args...)
+}
+
+func (d *doctor) warn(check, format string, args ...interface{}) {
+	d.report("WARN", check, format, args...)
+}
+
+func (d *doctor) fail(check, format string, args ...interface{}) {
+	d.failed = true
+	d.report("FAIL", check, format, args...)
+}
+
+func (d *doctor) report(status, check, format string, args ...interface{}) {
+	fmt.Printf("[%-4s] %-12s %s\n", status, check, fmt.Sprintf(format, args...))
+}
+
+func doctorCommand(args []string) (action, error) {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return func(client *netgear.Client) error {
+		return (&doctor{client: client}).run()
+	}, nil
+}
+
+func (d *doctor) run() error {
+	fmt.Printf("Checking %s\n\n", d.client.Host)
+
+	if !d.checkReachable() || !d.checkAuth() {
+		return fmt.Errorf("\nDoctor found problems that prevent further checks")
+	}
+
+	info := d.checkInfo()
+	d.checkFeatures()
+	d.checkDevices()
+	d.checkClock()
+
+	if info != nil {
+		d.checkQuirks(info)
+	}
+
+	if d.failed {
+		return fmt.Errorf("\nDoctor found problems")
+	}
+
+	fmt.Println("\nNo problems found")
+
+	return nil
+}
+
+// checkReachable connects to the configured port, and when that fails looks
+// for the other ports firmware commonly uses. Connections are made through
+// the clients dialer, so the interface and TLS options are respected.
+func (d *doctor) checkReachable() bool {
+	err := d.dial(d.client.Port)
+	if err == nil {
+		d.ok("reachable", "Connected to %s:%d", d.client.Host, d.client.Port)
+		return true
+	}
+
+	certErr := &netgear.CertificateChangedError{}
+	if errors.As(err, &certErr) {
+		d.fail("reachable", "%s", certErr)
+		return false
+	}
+
+	for _, port := range doctorPorts {
+		if port == d.client.Port || d.dial(port) != nil {
+			continue
+		}
+
+		d.fail("port", "Port %d is closed but %d is open, try -port %d", d.client.Port, port, port)
+		return false
+	}
+
+	d.fail("reachable", "Unable to connect to %s on any known port, %s. Check the host and that the router is on this network", d.client.Host, err)
+
+	return false
+}
+
+func (d *doctor) dial(port int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	conn, err := d.client.Dial(ctx, port)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+func (d *doctor) checkAuth() bool {
+	err := login(d.client)
+
+	respErr := &netgear.ResponseError{}
+	switch {
+	case err == nil:
+		d.ok("auth", "Logged in as %s", d.client.Username)
+		return true
+	case errors.As(err, &respErr):
+		meaning, ok := loginResponseCodes[respErr.Code]
+		if !ok {
+			meaning = "Unknown response code"
+		}
+		d.fail("auth", "Login failed with response code %03d, %s", respErr.Code, meaning)
+	default:
+		d.fail("auth", "Login failed, %s", err)
+	}
+
+	return false
+}
+
+func (d *doctor) checkInfo() *netgear.RouterInfo {
+	info, err := d.client.Info()
+	if err != nil {
+		d.warn("model", "Unable to read the router model, %s", err)
+		return nil
+	}
+
+	d.ok("model", "%s running firmware %s", info.Model, info.Firmware)
+
+	return info
+}
+
+func (d *doctor) checkFeatures() {
+	features, err := d.client.Features()
+	if err != nil {
+		d.warn("features", "No feature list published, older firmware may not support newer APIs")
+		return
+	}
+
+	d.ok("features", "Router publishes %d features", len(features))
+}
+
+func (d *doctor) checkDevices() {
+	list, err := d.client.DeviceList()
+	if err != nil {
+		d.fail("devices", "Unable to parse the attached devices, %s. Please report this along with `netgear fixtures capture` output", err)
+		return
+	}
+
+	if !list.Complete() {
+		d.warn("devices", "Router reported %d devices but %d were listed, the response may be truncated", list.Reported, list.Listed())
+		return
+	}
+
+	d.ok("devices", "Parsed %d attached devices", len(list.Devices))
+}
+
+func (d *doctor) checkClock() {
+	skew, err := d.client.ClockSkew()
+	if err != nil {
+		d.warn("clock", "Unable to determine the router clock, %s", err)
+		return
+	}
+
+	// The traffic meter catches routers whose time zone is misconfigured,
+	// which the Date header does not reflect
+	if meterSkew, err := d.client.TrafficMeterClockSkew(); err == nil && meterSkew > 0 && meterSkew > skew {
+		skew = meterSkew
+	}
+
+	if skew <= maxClockSkew && skew >= -maxClockSkew {
+		d.ok("clock", "Router clock is within %s", maxClockSkew)
+		return
+	}
+
+	d.warn("clock", "Router clock is off by %s, schedules and traffic meter resets will be wrong. Check the routers NTP settings", skew.Round(time.Second))
+}
+
+// checkQuirks reports the known quirks of the model, only warning about
+// APIs the model does not support
+func (d *doctor) checkQuirks(info *netgear.RouterInfo) {
+	quirks := netgear.ModelQuirks(info.Model)
+
+	sort.SliceStable(quirks, func(i, j int) bool {
+		return quirks[i].API < quirks[j].API
+	})
+
+	for _, quirk := range quirks {
+		switch quirk.Support {
+		case netgear.Supported:
+			d.ok("quirk", "%s: %s", quirk.API, quirk.Note)
+		case netgear.Unsupported:
+			d.warn("quirk", "%s is not supported: %s", quirk.API, quirk.Note)
+		default:
+			d.info("quirk", "%s: %s", quirk.API, quirk.Note)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// Classes of errors reported with -errors json
+const (
+	classAuth        = "auth"
+	classUnsupported = "unsupported"
+	classRouter      = "router"
+	classUnreachable = "unreachable"
+	classIncomplete  = "incomplete"
+	classCertificate = "certificate"
+	classPartial     = "partial"
+	classUsage       = "usage"
+	classError       = "error"
+)
+
+// cliError is the structured form of a failure, so orchestration can tell
+// rejected credentials from an unreachable router without parsing the
+// message
+type cliError struct {
+	Class     string `json:"class"`
+	Message   string `json:"message"`
+	Code      int    `json:"code,omitempty"`
+	Op        string `json:"op,omitempty"`
+	Action    string `json:"action,omitempty"`
+	Retryable bool   `json:"retryable"`
+
+	// Failures are the individual failures of a partially failed batch
+	Failures []cliError `json:"failures,omitempty"`
+}
+
+// classifyError determines the class of an error and if retrying the command
+// may succeed
+func classifyError(err error) cliError {
+	e := cliError{Class: classError, Message: err.Error()}
+
+	respErr := &netgear.ResponseError{}
+	countErr := &netgear.DeviceCountError{}
+	multiErr := &netgear.MultiError{}
+	certErr := &netgear.CertificateChangedError{}
+	netErr := net.Error(nil)
+
+	switch {
+	case errors.As(err, &multiErr):
+		e.Class = classPartial
+		e.Retryable = true
+
+		for _, failed := range multiErr.Failed {
+			failure := classifyError(failed)
+			e.Retryable = e.Retryable && failure.Retryable
+			e.Failures = append(e.Failures, failure)
+		}
+	case errors.As(err, &respErr):
+		e.Code = respErr.Code
+		e.Op = respErr.Op
+		e.Action = respErr.Action
+
+		switch respErr.Code {
+		case 401:
+			e.Class = classAuth
+		case 501:
+			e.Class = classUnsupported
+		default:
+			e.Class = classRouter
+		}
+	case errors.As(err, &certErr):
+		e.Class = classCertificate
+	case errors.As(err, &countErr):
+		e.Class = classIncomplete
+		e.Retryable = true
+	case errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded):
+		e.Class = classUnreachable
+		e.Retryable = true
+	}
+
+	return e
+}
+
+// reportError writes an error to w in the given format, either text or json
+func reportError(w io.Writer, format string, e cliError) {
+	if format != "json" {
+		fmt.Fprintf(w, "%s\n", e.Message)
+		return
+	}
+
+	out, _ := json.Marshal(e)
+	fmt.Fprintf(w, "%s\n", out)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+// fixtureActions are the actions recorded by the fixtures capture command
+var fixtureActions = []string{
+	soapconst.DeviceInfoGetInfo,
+	soapconst.DeviceInfoGetAttachDevice,
+	soapconst.DeviceInfoGetAttachDevice2,
+	soapconst.DeviceInfoGetSupportFeatureListXML,
+	soapconst.DeviceConfigGetTrafficMeterOptions,
+	soapconst.DeviceConfigGetTrafficMeterStatistics,
+	soapconst.WANIPConnectionGetInfo,
+	soapconst.WLANConfigurationGetInfo,
+	soapconst.WLANConfigurationGet5GInfo,
+	soapconst.WLANConfigurationGetGuestAccessEnabled,
+	soapconst.WLANConfigurationGetGuestAccessNetworkInfo,
+}
+
+func fixturesCommand(args []string) (action, error) {
+	if len(args) == 0 || args[0] != "capture" {
+		return nil, fmt.Errorf("Usage: fixtures capture [-out dir]")
+	}
+
+	flags := flag.NewFlagSet("fixtures capture", flag.ContinueOnError)
+	out := flags.String("out", ".", "Directory to write the captured fixtures to")
+	if err := flags.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	return func(client *netgear.Client) error { return captureFixtures(client, *out) }, nil
+}
+
+func captureFixtures(client *netgear.Client, out string) error {
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+
+	if err := login(client); err != nil {
+		return err
+	}
+
+	info, err := client.Info()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Router:   %s\n", info.Model)
+	fmt.Printf("Firmware: %s\n\n", info.Firmware)
+
+	for _, action := range fixtureActions {
+		payload, err := client.RawSOAP(action)
+		if err != nil {
+			fmt.Printf("Skipped %s: %s\n", action, err)
+			continue
+		}
+
+		path, err := netgeartest.WriteFixture(out, netgeartest.Fixture{
+			Model:    info.Model,
+			Firmware: info.Firmware,
+			Action:   action,
+			Payload:  string(payload),
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Captured %s\n", path)
+	}
+
+	fmt.Println()
+	fmt.Println("Payloads have been sanitized, review them for any remaining")
+	fmt.Println("identifying details before contributing them.")
+
+	return nil
+}
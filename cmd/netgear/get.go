@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// getter looks up a single value. The path arguments are the remaining
+// components of the dotted path after the getters prefix.
+type getter func(client *netgear.Client, path []string) (string, error)
+
+var getters = map[string]getter{
+	"router": getRouter,
+	"wan":    getWAN,
+	"wifi":   getWifi,
+	"device": getDevice,
+}
+
+func getCommand(args []string) (action, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("Usage: get <path>, for example wan.ip, wifi.2g.ssid, or device.<mac>.signal")
+	}
+
+	path := strings.Split(args[0], ".")
+
+	get, ok := getters[path[0]]
+	if !ok {
+		return nil, fmt.Errorf("Unknown path %q, expected router, wan, wifi, or device", args[0])
+	}
+
+	return func(client *netgear.Client) error { return printValue(client, get, path) }, nil
+}
+
+func printValue(client *netgear.Client, get getter, path []string) error {
+	if err := login(client); err != nil {
+		return err
+	}
+
+	value, err := get(client, path[1:])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(value)
+
+	return nil
+}
+
+// field picks a named value, reporting the available fields when it is
+// unknown
+func field(path []string, fields map[string]string) (string, error) {
+	if len(path) != 1 {
+		return "", fmt.Errorf("Expected a single field, one of: %s", fieldNames(fields))
+	}
+
+	value, ok := fields[path[0]]
+	if !ok {
+		return "", fmt.Errorf("Unknown field %q, expected one of: %s", path[0], fieldNames(fields))
+	}
+
+	return value, nil
+}
+
+func fieldNames(fields map[string]string) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
+
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+
+	return ip.String()
+}
+
+func getRouter(client *netgear.Client, path []string) (string, error) {
+	info, err := client.Info()
+	if err != nil {
+		return "", err
+	}
+
+	return field(path, map[string]string{
+		"model":    info.Model,
+		"firmware": info.Firmware,
+		"hardware": info.Hardware,
+		"serial":   info.SerialNumber,
+		"name":     info.DeviceName,
+	})
+}
+
+func getWAN(client *netgear.Client, path []string) (string, error) {
+	wan, err := client.WAN()
+	if err != nil {
+		return "", err
+	}
+
+	dns := make([]string, len(wan.DNSServers))
+	for i, ip := range wan.DNSServers {
+		dns[i] = ip.String()
+	}
+
+	return field(path, map[string]string{
+		"ip":      ipString(wan.ExternalIP),
+		"netmask": ipString(wan.SubnetMask),
+		"gateway": ipString(wan.Gateway),
+		"dns":     strings.Join(dns, " "),
+		"mac":     client.FormatMAC(wan.MAC),
+		"type":    wan.AddressingType,
+	})
+}
+
+func getWifi(client *netgear.Client, path []string) (string, error) {
+	if len(path) == 0 {
+		return "", fmt.Errorf("Expected a band, either 2g or 5g")
+	}
+
+	info, err := client.WirelessInfo(netgear.Band(path[0]))
+	if err != nil {
+		return "", err
+	}
+
+	return field(path[1:], map[string]string{
+		"enabled":  strconv.FormatBool(info.Enabled),
+		"ssid":     info.SSID,
+		"channel":  info.Channel,
+		"mode":     info.Mode,
+		"security": info.Security,
+		"region":   info.Region,
+		"status":   info.Status,
+	})
+}
+
+func getDevice(client *netgear.Client, path []string) (string, error) {
+	if len(path) == 0 {
+		return "", fmt.Errorf("Expected a device MAC address")
+	}
+
+	mac, path, err := pathMAC(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Detailed devices are not supported by older firmware, the detailed
+	// fields are left empty there.
+	devices, err := client.DetailedDevices()
+	if err != nil {
+		devices, err = client.Devices()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, dev := range devices {
+		if dev.MAC.String() != mac.String() {
+			continue
+		}
+
+		return field(path, map[string]string{
+			"ip":              ipString(dev.IP),
+			"name":            dev.Name,
+			"label":           dev.Label,
+			"type":            dev.Type,
+			"signal":          strconv.Itoa(dev.Signal),
+			"link_rate":       strconv.Itoa(dev.LinkRate),
+			"connection_type": dev.ConnectionType,
+			"ssid":            dev.SSID,
+			"model":           dev.Model,
+		})
+	}
+
+	return "", fmt.Errorf("Device %s is not attached", client.FormatMAC(mac))
+}
+
+// pathMAC takes the MAC address from the start of a path. MAC addresses in
+// the dotted format span several path components, so the shortest prefix of
+// the path forming a MAC address is used, and the remaining path returned.
+func pathMAC(path []string) (net.HardwareAddr, []string, error) {
+	var err error
+
+	for i := 1; i <= len(path); i++ {
+		var mac net.HardwareAddr
+		if mac, err = net.ParseMAC(strings.Join(path[:i], ".")); err == nil {
+			return mac, path[i:], nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("Path does not start with a MAC address: %s", err)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear/netgeartest"
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+func TestPathMAC(t *testing.T) {
+	tests := []struct {
+		path  string
+		mac   string
+		field []string
+	}{
+		{"aa:bb:cc:dd:ee:ff.signal", "aa:bb:cc:dd:ee:ff", []string{"signal"}},
+		{"aa-bb-cc-dd-ee-ff.ip", "aa:bb:cc:dd:ee:ff", []string{"ip"}},
+		{"aabb.ccdd.eeff.signal", "aa:bb:cc:dd:ee:ff", []string{"signal"}},
+		{"aabb.ccdd.eeff", "aa:bb:cc:dd:ee:ff", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			mac, field, err := pathMAC(strings.Split(tt.path, "."))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if mac.String() != tt.mac || !reflect.DeepEqual(field, tt.field) {
+				t.Errorf("Expected %s %v, got %s %v", tt.mac, tt.field, mac, field)
+			}
+		})
+	}
+
+	if _, _, err := pathMAC([]string{"laptop", "signal"}); err == nil {
+		t.Error("Expected an error for a path without a MAC address")
+	}
+}
+
+func TestGet(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "laptop"))
+
+	tests := []struct {
+		path  string
+		value string
+	}{
+		{"router.model", "R7000"},
+		{"router.firmware", "V1.0.11.116_10.2.100"},
+		{"device.aa:bb:cc:00:00:01.ip", "192.168.1.2"},
+		{"device.aabb.cc00.0001.name", "laptop"},
+		{"device.aa-bb-cc-00-00-01.signal", "80"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := run(t, server, "get", tt.path)
+			expectExit(t, r, 0)
+
+			if r.stdout != tt.value+"\n" {
+				t.Errorf("Expected %q, got %q", tt.value, r.stdout)
+			}
+		})
+	}
+}
+
+func TestGetDetailedDevice(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetServiceVersion(soapconst.DeviceInfo, 2)
+
+	device := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "laptop")
+	device.Model = "MacBook Pro"
+	server.SetDevices(device)
+
+	r := run(t, server, "get", "device.aa:bb:cc:00:00:01.model")
+	expectExit(t, r, 0)
+
+	if r.stdout != "MacBook Pro\n" {
+		t.Errorf("Expected the model from the detailed devices, got %q", r.stdout)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "laptop"))
+
+	tests := []struct {
+		path    string
+		code    int
+		class   string
+		message string
+	}{
+		{"device.aa:bb:cc:00:00:02.ip", 1, classError, "is not attached"},
+		{"router.color", 1, classError, "Unknown field"},
+
+		// Unknown paths are rejected before connecting to the router
+		{"lan.ip", 2, classUsage, `Unknown path "lan.ip"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			r := run(t, server, "-errors", "json", "get", tt.path)
+			expectExit(t, r, tt.code)
+
+			e := decodeError(t, r.stderr)
+			if message, _ := e["message"].(string); e["class"] != tt.class || !strings.Contains(message, tt.message) {
+				t.Errorf("Expected a %s error containing %q, got %s", tt.class, tt.message, r.stderr)
+			}
+			if r.stdout != "" {
+				t.Errorf("Expected nothing written to stdout, got %q", r.stdout)
+			}
+		})
+	}
+}
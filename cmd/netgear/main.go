@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+var (
+	host     = flag.String("host", "192.168.1.1", "Your netgear router address")
+	port     = flag.Int("port", 5000, "Your netgear router SOAP port")
+	username = flag.String("username", "admin", "Your netgear router username")
+	password = flag.String("password", "", "Your netgear router password")
+	iface    = flag.String("interface", "", "Network interface to reach the router through")
+
+	trustPath = flag.String("trust-store", "", "Connect using HTTPS, trusting the router certificate on first use and recording it in this file")
+
+	errorFormat = flag.String("errors", "text", "Format of errors written to stderr, text or json")
+)
+
+// command implements a netgear subcommand. The arguments following the
+// command name are parsed before connecting to the router, returning the
+// action running the command, so a batch rejects invalid lines before
+// changing anything.
+type command func(args []string) (action, error)
+
+// action runs a parsed command against the router
+type action func(client *netgear.Client) error
+
+var commands = map[string]command{
+	"auth":        authCommand,
+	"doctor":      doctorCommand,
+	"fixtures":    fixturesCommand,
+	"get":         getCommand,
+	"pause":       pauseCommand,
+	"resume":      resumeCommand,
+	"top-talkers": topTalkersCommand,
+	"traffic":     trafficCommand,
+}
+
+// authenticated is set once logged in, so commands run in a batch share a
+// single session
+var authenticated bool
+
+// configContext carries the configuration transaction of a batch, so the
+// changes made by each of its commands join it
+var configContext = context.Background()
+
+// login authenticates the client, unless it has already been
+func login(client *netgear.Client) error {
+	if authenticated {
+		return nil
+	}
+
+	if err := client.Login(); err != nil {
+		return err
+	}
+
+	authenticated = true
+
+	return nil
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] <command> [args]\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  auth test           Validate credentials with a single login attempt\n")
+	fmt.Fprintf(os.Stderr, "  doctor              Check for common configuration problems\n")
+	fmt.Fprintf(os.Stderr, "  fixtures capture    Record sanitized responses for the fixture corpus\n")
+	fmt.Fprintf(os.Stderr, "  get <path>          Print a single value, such as wan.ip or device.<mac>.signal\n")
+	fmt.Fprintf(os.Stderr, "  pause <mac>         Block a device from accessing the internet\n")
+	fmt.Fprintf(os.Stderr, "  resume <mac>        Allow a paused device to access the internet again\n")
+	fmt.Fprintf(os.Stderr, "  top-talkers [-n 10] Rank attached devices by their traffic\n")
+	fmt.Fprintf(os.Stderr, "  traffic [-forecast] Print the traffic meter, or project usage against the monthly limit\n")
+	fmt.Fprintf(os.Stderr, "  batch <file>        Run commands from a file, or - for stdin, in one session\n")
+	fmt.Fprintf(os.Stderr, "                      With -keep-going every failure is reported instead of the first\n\n")
+	fmt.Fprintf(os.Stderr, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	if *errorFormat != "text" && *errorFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown error format %q, expected text or json\n\n", *errorFormat)
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		message := fmt.Sprintf("Unknown command %q", flag.Arg(0))
+		if *errorFormat == "json" {
+			reportError(os.Stderr, *errorFormat, cliError{Class: classUsage, Message: message})
+			os.Exit(2)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s\n\n", message)
+		usage()
+		os.Exit(2)
+	}
+
+	run, err := cmd(flag.Args()[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		reportError(os.Stderr, *errorFormat, cliError{Class: classUsage, Message: err.Error()})
+		os.Exit(2)
+	}
+
+	opts := []netgear.ClientOption{}
+	if *iface != "" {
+		opts = append(opts, netgear.WithInterface(*iface))
+	}
+	if *trustPath != "" {
+		opts = append(opts, netgear.WithTrustOnFirstUse(&netgear.FileTrustStore{Path: *trustPath}))
+	}
+
+	client := netgear.NewClient(*host, *username, *password, opts...)
+	client.Port = *port
+
+	if err := run(client); err != nil {
+		reportError(os.Stderr, *errorFormat, classifyError(err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+// binary is the path of the netgear command built for the tests
+var binary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "netgear")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	binary = filepath.Join(dir, "netgear")
+
+	build := exec.Command("go", "build", "-o", binary, ".")
+	build.Stderr = os.Stderr
+
+	if err := build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to build the netgear command: %s\n", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// result is the outcome of running the netgear command
+type result struct {
+	stdout string
+	stderr string
+	code   int
+}
+
+// run runs the netgear command against the mock router, with the flags
+// connecting to it preceding args. The command inherits the environment,
+// see t.Setenv.
+func run(t *testing.T, server *netgeartest.Server, args ...string) result {
+	t.Helper()
+
+	addr := server.Listener.Addr().(*net.TCPAddr)
+	flags := []string{
+		"-host", addr.IP.String(),
+		"-port", strconv.Itoa(addr.Port),
+		"-username", server.Username,
+		"-password", server.Password,
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	cmd := exec.Command(binary, append(flags, args...)...)
+	cmd.Stdin = strings.NewReader("")
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	r := result{}
+
+	err := cmd.Run()
+	exitErr := &exec.ExitError{}
+	switch {
+	case errors.As(err, &exitErr):
+		r.code = exitErr.ExitCode()
+	case err != nil:
+		t.Fatal(err)
+	}
+
+	r.stdout, r.stderr = stdout.String(), stderr.String()
+
+	return r
+}
+
+// expectExit fails the test when the command did not exit with code
+func expectExit(t *testing.T, r result, code int) {
+	t.Helper()
+
+	if r.code != code {
+		t.Fatalf("Expected exit code %d, got %d\nstdout:\n%s\nstderr:\n%s", code, r.code, r.stdout, r.stderr)
+	}
+}
+
+// errorSchema is the JSON type of each field of an error written with
+// -errors json. Orchestration relies on these fields, so renamed or retyped
+// fields must be caught.
+var errorSchema = map[string]string{
+	"class":     "string",
+	"message":   "string",
+	"code":      "number",
+	"op":        "string",
+	"action":    "string",
+	"retryable": "bool",
+	"failures":  "array",
+}
+
+// decodeError decodes an error written with -errors json, checking it
+// against the errorSchema
+func decodeError(t *testing.T, stderr string) map[string]any {
+	t.Helper()
+
+	e := map[string]any{}
+	if err := json.Unmarshal([]byte(stderr), &e); err != nil {
+		t.Fatalf("Expected a JSON error, got %q: %s", stderr, err)
+	}
+
+	checkErrorSchema(t, e)
+
+	return e
+}
+
+func checkErrorSchema(t *testing.T, e map[string]any) {
+	t.Helper()
+
+	for _, required := range []string{"class", "message", "retryable"} {
+		if _, ok := e[required]; !ok {
+			t.Errorf("Expected the %q field in %v", required, e)
+		}
+	}
+
+	for key, value := range e {
+		kind := ""
+		switch value.(type) {
+		case string:
+			kind = "string"
+		case float64:
+			kind = "number"
+		case bool:
+			kind = "bool"
+		case []any:
+			kind = "array"
+		}
+
+		if expected, ok := errorSchema[key]; !ok || kind != expected {
+			t.Errorf("Unexpected field %q of type %s in %v", key, kind, e)
+		}
+	}
+
+	failures, _ := e["failures"].([]any)
+	for _, failure := range failures {
+		f, ok := failure.(map[string]any)
+		if !ok {
+			t.Fatalf("Expected each failure to be an object, got %v", failure)
+		}
+		checkErrorSchema(t, f)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	tests := []struct {
+		name   string
+		args   []string
+		stderr string
+	}{
+		{"no command", nil, "Usage:"},
+		{"unknown command", []string{"reboot"}, `Unknown command "reboot"`},
+		{"unknown error format", []string{"-errors", "xml", "get", "wan.ip"}, `Unknown error format "xml"`},
+		{"invalid arguments", []string{"pause", "laptop"}, "address laptop: invalid MAC address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := run(t, server, tt.args...)
+			expectExit(t, r, 2)
+
+			if !strings.Contains(r.stderr, tt.stderr) {
+				t.Errorf("Expected %q in stderr, got:\n%s", tt.stderr, r.stderr)
+			}
+			if r.stdout != "" {
+				t.Errorf("Expected nothing written to stdout, got:\n%s", r.stdout)
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		for _, args := range [][]string{{"reboot"}, {"pause", "laptop"}} {
+			r := run(t, server, append([]string{"-errors", "json"}, args...)...)
+			expectExit(t, r, 2)
+
+			if e := decodeError(t, r.stderr); e["class"] != classUsage || e["retryable"] != false {
+				t.Errorf("Expected a usage error, got %s", r.stderr)
+			}
+		}
+	})
+}
+
+func TestAuthTest(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	// Login attempts made by auth test are recorded in the cache directory
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	r := run(t, server, "auth", "test")
+	expectExit(t, r, 0)
+
+	if !strings.Contains(r.stdout, "Result:    OK, credentials accepted") {
+		t.Errorf("Expected the credentials accepted, got:\n%s", r.stdout)
+	}
+
+	// A second attempt within the interval is refused without logging in
+	r = run(t, server, "auth", "test")
+	expectExit(t, r, 1)
+
+	if !strings.Contains(r.stderr, "Last login attempt") {
+		t.Errorf("Expected the attempt to be rate limited, got:\n%s", r.stderr)
+	}
+}
+
+func TestAuthTestRejected(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.FailAuth(1)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	r := run(t, server, "-errors", "json", "auth", "test")
+	expectExit(t, r, 1)
+
+	if !strings.Contains(r.stdout, "Response code 401, The credentials were rejected by the router") {
+		t.Errorf("Expected the response code explained, got:\n%s", r.stdout)
+	}
+
+	if e := decodeError(t, r.stderr); e["class"] != classAuth || e["code"] != float64(401) || e["retryable"] != false {
+		t.Errorf("Expected a non retryable auth error, got %s", r.stderr)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	commands := [][]string{
+		{"get", "wan.ip"},
+		{"pause", "aa:bb:cc:00:00:01"},
+		{"resume", "aa:bb:cc:00:00:01"},
+		{"traffic"},
+		{"top-talkers"},
+	}
+
+	for _, args := range commands {
+		t.Run(args[0], func(t *testing.T) {
+			server := netgeartest.Start(t)
+
+			server.FailAuth(1)
+			r := run(t, server, append([]string{"-errors", "json"}, args...)...)
+			expectExit(t, r, 1)
+
+			e := decodeError(t, r.stderr)
+			if e["class"] != classAuth || e["code"] != float64(401) || e["op"] != "login" || e["message"] == "" {
+				t.Errorf("Expected the rejected login, got %s", r.stderr)
+			}
+
+			// The text format writes the message alone
+			server.FailAuth(1)
+			r = run(t, server, args...)
+			expectExit(t, r, 1)
+
+			if text := strings.TrimSpace(r.stderr); text != e["message"] {
+				t.Errorf("Expected the plain message %q, got %q", e["message"], text)
+			}
+		})
+	}
+}
+
+func TestErrorsUnreachable(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.Close()
+
+	r := run(t, server, "-errors", "json", "get", "wan.ip")
+	expectExit(t, r, 1)
+
+	if e := decodeError(t, r.stderr); e["class"] != classUnreachable || e["retryable"] != true {
+		t.Errorf("Expected a retryable unreachable error, got %s", r.stderr)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+func pauseCommand(args []string) (action, error) {
+	return setPaused(args, "pause", (*netgear.Client).PauseInternetContext)
+}
+
+func resumeCommand(args []string) (action, error) {
+	return setPaused(args, "resume", (*netgear.Client).ResumeInternetContext)
+}
+
+func setPaused(args []string, name string, fn func(*netgear.Client, context.Context, net.HardwareAddr) error) (action, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("Usage: %s <mac>", name)
+	}
+
+	mac, err := net.ParseMAC(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return func(client *netgear.Client) error {
+		if err := login(client); err != nil {
+			return err
+		}
+
+		return fn(client, configContext, mac)
+	}, nil
+}
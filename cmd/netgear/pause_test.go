@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"go.evanpurkhiser.com/netgear/netgeartest"
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+func TestPauseResume(t *testing.T) {
+	server := netgeartest.Start(t)
+	laptop := netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")
+
+	r := run(t, server, "pause", "aa:bb:cc:00:00:01")
+	expectExit(t, r, 0)
+
+	if !server.Blocked(laptop) {
+		t.Error("Expected the laptop to be paused")
+	}
+
+	r = run(t, server, "resume", "aa:bb:cc:00:00:01")
+	expectExit(t, r, 0)
+
+	if server.Blocked(laptop) {
+		t.Error("Expected the laptop to be resumed")
+	}
+
+	if r.stdout != "" || r.stderr != "" {
+		t.Errorf("Expected no output, got stdout %q and stderr %q", r.stdout, r.stderr)
+	}
+}
+
+func TestPauseNotSupported(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetServiceVersion(soapconst.DeviceConfig, 0)
+
+	for _, command := range []string{"pause", "resume"} {
+		t.Run(command, func(t *testing.T) {
+			r := run(t, server, "-errors", "json", command, "aa:bb:cc:00:00:01")
+			expectExit(t, r, 1)
+
+			if e := decodeError(t, r.stderr); e["class"] != classUnsupported || e["code"] != float64(501) || e["retryable"] != false {
+				t.Errorf("Expected an unsupported error, got %s", r.stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+func topTalkersCommand(args []string) (action, error) {
+	flags := flag.NewFlagSet("top-talkers", flag.ContinueOnError)
+	n := flags.Int("n", 10, "Number of devices to list, 0 for every device")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return func(client *netgear.Client) error { return printTopTalkers(client, *n) }, nil
+}
+
+func printTopTalkers(client *netgear.Client, n int) error {
+	if err := login(client); err != nil {
+		return err
+	}
+
+	top, err := client.TopTalkers(n)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-4s %-17s %-24s %12s %12s %12s %6s\n", "Rank", "MAC", "Name", "Upload", "Download", "Total", "Share")
+
+	for i, t := range top.Talkers {
+		fmt.Printf("%-4d %-17s %-24s %12s %12s %12s %5.1f%%\n",
+			i+1,
+			client.FormatMAC(t.Device.MAC),
+			client.FormatLabel(t.Device),
+			megabytes(t.Upload),
+			megabytes(t.Download),
+			megabytes(t.Total),
+			t.Share*100,
+		)
+	}
+
+	fmt.Printf("%-4s %-17s %-24s %12s %12s %12s\n", "", "", "All devices", megabytes(top.Upload), megabytes(top.Download), megabytes(top.Total))
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+func trafficCommand(args []string) (action, error) {
+	flags := flag.NewFlagSet("traffic", flag.ContinueOnError)
+	forecast := flags.Bool("forecast", false, "Project the usage of the current month against the monthly limit")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return func(client *netgear.Client) error { return printTraffic(client, *forecast) }, nil
+}
+
+func printTraffic(client *netgear.Client, forecast bool) error {
+	if err := login(client); err != nil {
+		return err
+	}
+
+	if forecast {
+		return printForecast(client)
+	}
+
+	meter, err := client.TrafficMeter()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-12s %12s %12s %10s\n", "Period", "Upload", "Download", "Connected")
+
+	for _, period := range []struct {
+		name string
+		p    netgear.TrafficPeriod
+	}{
+		{"Today", meter.Today},
+		{"Yesterday", meter.Yesterday},
+		{"This week", meter.Week},
+		{"This month", meter.Month},
+		{"Last month", meter.LastMonth},
+	} {
+		fmt.Printf("%-12s %12s %12s %10s\n", period.name, megabytes(period.p.Upload), megabytes(period.p.Download), period.p.ConnectionTime)
+	}
+
+	return nil
+}
+
+// printForecast prints the projected usage of the month, failing when the
+// usage is projected to exceed the limit so the command can be used to alert
+func printForecast(client *netgear.Client) error {
+	f, err := client.ProjectedMonthlyUsage()
+	if err != nil {
+		return err
+	}
+
+	const dateFormat = "Jan 02 15:04"
+
+	fmt.Printf("%-12s %s to %s\n", "Month", f.Start.Format(dateFormat), f.End.Format(dateFormat))
+	fmt.Printf("%-12s %s\n", "Used", megabytes(f.Used))
+	fmt.Printf("%-12s %s per day\n", "Rate", megabytes(f.DailyRate))
+
+	if f.Limit == 0 {
+		fmt.Printf("%-12s %s, no monthly limit\n", "Projected", megabytes(f.Projected))
+		return nil
+	}
+
+	fmt.Printf("%-12s %s of %s limit\n", "Projected", megabytes(f.Projected), megabytes(f.Limit))
+
+	if f.Used >= f.Limit {
+		return fmt.Errorf("Monthly limit has been reached")
+	}
+
+	days, ok := f.DaysUntilCap()
+	if !ok {
+		return nil
+	}
+
+	fmt.Printf("%-12s in %.1f days, %s\n", "Limit", days, f.CapAt.Format(dateFormat))
+
+	return fmt.Errorf("Usage is projected to exceed the monthly limit")
+}
+
+func megabytes(mb float64) string {
+	if mb >= 1000 {
+		return fmt.Sprintf("%.1f GB", mb/1000)
+	}
+
+	return fmt.Sprintf("%.1f MB", mb)
+}
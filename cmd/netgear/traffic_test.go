@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func TestTraffic(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetTrafficMeter(netgear.TrafficMeterOptions{ControlOption: "No limit"}, netgear.TrafficMeter{
+		Today: netgear.TrafficPeriod{Upload: 12.5, Download: 250, ConnectionTime: 90 * time.Minute},
+		Month: netgear.TrafficPeriod{Upload: 400, Download: 2500},
+	})
+
+	r := run(t, server, "traffic")
+	expectExit(t, r, 0)
+
+	lines := strings.Split(strings.TrimSuffix(r.stdout, "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("Expected a header and five periods, got:\n%s", r.stdout)
+	}
+
+	expected := map[string][]string{
+		"Period":     {"Upload", "Download", "Connected"},
+		"Today":      {"12.5", "MB", "250.0", "MB", "1h30m0s"},
+		"This month": {"400.0", "MB", "2.5", "GB", "0s"},
+	}
+
+	for _, line := range lines {
+		for name, fields := range expected {
+			if !strings.HasPrefix(line, name+" ") {
+				continue
+			}
+
+			if got := strings.Fields(strings.TrimPrefix(line, name)); strings.Join(got, " ") != strings.Join(fields, " ") {
+				t.Errorf("Expected %s %v, got %q", name, fields, line)
+			}
+			delete(expected, name)
+		}
+	}
+
+	for name := range expected {
+		t.Errorf("Expected a %s line, got:\n%s", name, r.stdout)
+	}
+}
+
+func TestTrafficForecast(t *testing.T) {
+	tests := []struct {
+		name    string
+		options netgear.TrafficMeterOptions
+		code    int
+		stdout  string
+		stderr  string
+	}{
+		{"no limit", netgear.TrafficMeterOptions{ControlOption: "No limit"}, 0, "no monthly limit", ""},
+		{"limit reached", netgear.TrafficMeterOptions{ControlOption: "Both directions", MonthlyLimit: 1000}, 1, "of 1.0 GB limit", "Monthly limit has been reached"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := netgeartest.Start(t)
+			server.SetTrafficMeter(tt.options, netgear.TrafficMeter{
+				Month: netgear.TrafficPeriod{Upload: 400, Download: 800},
+			})
+
+			r := run(t, server, "traffic", "-forecast")
+			expectExit(t, r, tt.code)
+
+			if !strings.Contains(r.stdout, "Used         1.2 GB") || !strings.Contains(r.stdout, tt.stdout) {
+				t.Errorf("Expected the usage and %q, got:\n%s", tt.stdout, r.stdout)
+			}
+			if strings.TrimSpace(r.stderr) != tt.stderr {
+				t.Errorf("Expected %q in stderr, got %q", tt.stderr, r.stderr)
+			}
+		})
+	}
+}
+
+func TestTrafficNotSupported(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	for _, args := range [][]string{{"traffic"}, {"traffic", "-forecast"}} {
+		r := run(t, server, append([]string{"-errors", "json"}, args...)...)
+		expectExit(t, r, 1)
+
+		if e := decodeError(t, r.stderr); e["class"] != classUnsupported || e["code"] != float64(501) {
+			t.Errorf("Expected an unsupported error for %v, got %s", args, r.stderr)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package netgear_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func TestConfigureSerialized(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	mac := netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	outer := make(chan error, 1)
+	go func() {
+		outer <- client.Configure(context.Background(), func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+
+	<-started
+
+	// A change from another goroutine does not slip into the open
+	// transaction, it waits for a transaction of its own
+	paused := make(chan error, 1)
+	go func() { paused <- client.PauseInternet(mac) }()
+
+	select {
+	case err := <-paused:
+		t.Fatalf("Expected the change to wait for the open transaction, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+
+	if err := <-outer; err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-paused:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Change did not run once the transaction finished")
+	}
+
+	if !server.Blocked(mac) {
+		t.Error("Expected the device to be paused")
+	}
+}
+
+func TestConfigureNested(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	mac := netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Configure(context.Background(), func(ctx context.Context) error {
+			if err := client.PauseInternetContext(ctx, mac); err != nil {
+				return err
+			}
+
+			if status := client.ConfigStatus(); !status.Open {
+				t.Error("Expected the transaction to remain open after the nested change")
+			}
+
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Nested change waited on its own transaction")
+	}
+
+	if !server.Blocked(mac) {
+		t.Error("Expected the device to be paused")
+	}
+
+	if status := client.ConfigStatus(); status.Open {
+		t.Error("Expected the transaction to be finished")
+	}
+}
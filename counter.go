@@ -0,0 +1,77 @@
+package netgear
+
+import "time"
+
+// CounterState describes how a counter sample was interpreted
+type CounterState int
+
+// Counter sample states
+const (
+	// CounterFirst is the first sample, there is no increment yet
+	CounterFirst CounterState = iota
+
+	// CounterIncreased is a normal increase of the counter
+	CounterIncreased
+
+	// CounterWrapped is a counter passing its maximum and starting again
+	// from zero
+	CounterWrapped
+
+	// CounterReset is a counter restarting from zero, such as after the
+	// router reboots or the traffic meter period ends. The increment is the
+	// new counter value.
+	CounterReset
+
+	// CounterImplausible is an increase faster than the counters MaxRate,
+	// which is discarded
+	CounterImplausible
+)
+
+// Counter converts successive samples of a cumulative router counter into
+// increments, so that wraps and resets never produce negative or absurdly
+// large increments
+type Counter struct {
+	// Max is the value the counter wraps at. Zero indicates the counter only
+	// resets and never wraps.
+	Max float64
+
+	// MaxRate is the largest plausible increase per second. Zero disables
+	// the check.
+	MaxRate float64
+
+	last   float64
+	lastAt time.Time
+	seen   bool
+}
+
+// Add records a sample of the counter taken at the given time, returning the
+// increment since the previous sample
+func (c *Counter) Add(value float64, at time.Time) (float64, CounterState) {
+	last, lastAt, seen := c.last, c.lastAt, c.seen
+	c.last, c.lastAt, c.seen = value, at, true
+
+	if !seen {
+		return 0, CounterFirst
+	}
+
+	delta, state := value-last, CounterIncreased
+
+	// A decrease close to the maximum is a wrap, anything else is the
+	// counter having restarted from zero
+	if delta < 0 {
+		wrapped := c.Max - last + value
+
+		if c.Max > 0 && wrapped < c.Max/2 {
+			delta, state = wrapped, CounterWrapped
+		} else {
+			delta, state = value, CounterReset
+		}
+	}
+
+	elapsed := at.Sub(lastAt).Seconds()
+	if c.MaxRate > 0 && elapsed > 0 && delta/elapsed > c.MaxRate {
+		return 0, CounterImplausible
+	}
+
+	return delta, state
+}
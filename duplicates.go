@@ -0,0 +1,156 @@
+package netgear
+
+import (
+	"net"
+	"strings"
+)
+
+// DuplicatePolicy determines how devices listed more than once by the router
+// are handled. Some firmware lists a device once for each band it has been
+// seen on, which would otherwise be reported as separate devices sharing a
+// MAC address.
+type DuplicatePolicy int
+
+// Duplicate policies
+const (
+	// DuplicateKeep keeps every entry. Use AttachedDevice.Band to tell them
+	// apart, and IdentityMACBand to track them as separate devices. This is
+	// the default.
+	DuplicateKeep DuplicatePolicy = iota
+
+	// DuplicatePrefer5G keeps only the entry connected to the 5GHz band, or
+	// the entry with the highest link rate when the band is not known
+	DuplicatePrefer5G
+
+	// DuplicateMerge keeps the entry DuplicatePrefer5G would, with missing
+	// fields filled in from the other entries
+	DuplicateMerge
+)
+
+// WithDuplicatePolicy sets how devices listed more than once by the router
+// are handled
+func WithDuplicatePolicy(policy DuplicatePolicy) ClientOption {
+	return func(c *Client) {
+		c.duplicates = policy
+	}
+}
+
+// Band is the wireless band the device is connected to, empty for wired
+// devices and when unknown. The band is only known from the detailed
+// connection type, such as "2.4GHz" or "5GHz".
+func (d AttachedDevice) Band() Band {
+	switch {
+	case strings.HasPrefix(d.ConnectionType, "2.4"):
+		return Band2G
+	case strings.HasPrefix(d.ConnectionType, "5"):
+		return Band5G
+	}
+
+	return ""
+}
+
+// IdentityMACBand identifies devices by MAC address and band, so a device
+// listed once per band under DuplicateKeep is tracked as separate devices.
+// Devices with an unknown band are identified by MAC address alone.
+func IdentityMACBand(dev AttachedDevice) string {
+	band := dev.Band()
+	if band == "" {
+		return IdentityMAC(dev)
+	}
+
+	return IdentityMAC(dev) + "/" + string(band)
+}
+
+// dedupDevices applies the duplicate policy to the devices, returning the
+// remaining devices and the number of entries removed
+func dedupDevices(devices []AttachedDevice, policy DuplicatePolicy) ([]AttachedDevice, int) {
+	if policy == DuplicateKeep {
+		return devices, 0
+	}
+
+	index := make(map[string]int, len(devices))
+	deduped := make([]AttachedDevice, 0, len(devices))
+
+	for _, dev := range devices {
+		i, ok := index[string(dev.MAC)]
+		if !ok {
+			index[string(dev.MAC)] = len(deduped)
+			deduped = append(deduped, dev)
+			continue
+		}
+
+		preferred, other := deduped[i], dev
+		if preferDuplicate(dev, deduped[i]) {
+			preferred, other = dev, deduped[i]
+		}
+
+		if policy == DuplicateMerge {
+			preferred.fillFrom(other)
+		}
+
+		deduped[i] = preferred
+	}
+
+	return deduped, len(devices) - len(deduped)
+}
+
+// preferDuplicate reports if entry a of a duplicated device is preferred over
+// entry b
+func preferDuplicate(a, b AttachedDevice) bool {
+	rank := map[Band]int{Band5G: 2, Band2G: 1}
+
+	if ra, rb := rank[a.Band()], rank[b.Band()]; ra != rb {
+		return ra > rb
+	}
+
+	return a.LinkRate > b.LinkRate
+}
+
+// fillFrom fills the fields missing from the device using another entry for
+// the same device
+func (d *AttachedDevice) fillFrom(other AttachedDevice) {
+	if d.IP == nil {
+		d.IP = other.IP
+	}
+	if d.Name == "" {
+		d.Name = other.Name
+	}
+	if d.Signal == 0 {
+		d.Signal = other.Signal
+	}
+	if d.LinkRate == 0 {
+		d.LinkRate = other.LinkRate
+	}
+	if d.ConnectionType == "" {
+		d.ConnectionType = other.ConnectionType
+	}
+	if d.SSID == "" {
+		d.SSID = other.SSID
+	}
+	if d.AccessPoint == nil {
+		d.AccessPoint = other.AccessPoint
+	}
+	if d.Model == "" {
+		d.Model = other.Model
+	}
+
+	if d.Upload == 0 && d.Download == 0 {
+		d.Upload, d.Download = other.Upload, other.Download
+	}
+
+	for _, ip := range other.IPv6 {
+		if !containsIP(d.IPv6, ip) {
+			d.IPv6 = append(d.IPv6, ip)
+		}
+	}
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, existing := range ips {
+		if existing.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
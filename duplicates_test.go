@@ -0,0 +1,63 @@
+package netgear_test
+
+import (
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func TestDuplicatePolicy(t *testing.T) {
+	phone5G := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone")
+	phone2G := phone5G
+	phone2G.ConnectionType = "2.4GHz"
+
+	tests := []struct {
+		name  string
+		opts  []netgear.ClientOption
+		bands []netgear.Band
+	}{
+		{"default", nil, []netgear.Band{netgear.Band2G, netgear.Band5G}},
+		{"prefer 5G", []netgear.ClientOption{netgear.WithDuplicatePolicy(netgear.DuplicatePrefer5G)}, []netgear.Band{netgear.Band5G}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := netgeartest.Start(t)
+			server.SetDevices(phone2G, phone5G)
+
+			client := server.Client(tt.opts...)
+			if err := client.Login(); err != nil {
+				t.Fatal(err)
+			}
+
+			devices, err := client.DetailedDevices()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(devices) != len(tt.bands) {
+				t.Fatalf("Expected %d entries, got %+v", len(tt.bands), devices)
+			}
+
+			for i, dev := range devices {
+				if dev.Band() != tt.bands[i] {
+					t.Errorf("Expected entry %d on band %q, got %q", i, tt.bands[i], dev.Band())
+				}
+			}
+		})
+	}
+}
+
+func TestIdentityMACBand(t *testing.T) {
+	phone := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone")
+
+	if id := netgear.IdentityMACBand(phone); id != "aa:bb:cc:00:00:01/5g" {
+		t.Errorf("Expected the band in the identity, got %q", id)
+	}
+
+	phone.ConnectionType = ""
+	if id := netgear.IdentityMACBand(phone); id != netgear.IdentityMAC(phone) {
+		t.Errorf("Expected the MAC address alone when the band is unknown, got %q", id)
+	}
+}
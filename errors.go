@@ -0,0 +1,131 @@
+package netgear
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ResponseError is returned when the router responds to an action with a
+// non-zero response code
+type ResponseError struct {
+	// Op describes the operation that failed, such as "login"
+	Op   string
+	Code int
+
+	// Action is the SOAP action which responded, such as
+	// "ParentalControl#Authenticate"
+	Action string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("Unable to %s, got status code %d", e.Op, e.Code)
+}
+
+// DeviceCountError is returned when the number of devices in the attached
+// device list does not match the count reported by the router, which
+// indicates the response was truncated
+type DeviceCountError struct {
+	Reported int
+	Parsed   int
+}
+
+func (e *DeviceCountError) Error() string {
+	return fmt.Sprintf("Router reported %d devices but %d were listed", e.Reported, e.Parsed)
+}
+
+// Target identifies what a single operation of a batch applied to, unused
+// fields are left empty
+type Target struct {
+	Router string
+	Action string
+	Device net.HardwareAddr
+}
+
+func (t Target) String() string {
+	parts := []string{}
+	if t.Action != "" {
+		parts = append(parts, t.Action)
+	}
+	if t.Device != nil {
+		parts = append(parts, t.Device.String())
+	}
+	if t.Router != "" {
+		parts = append(parts, "on "+t.Router)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// TargetError is the failure of a single operation of a batch
+type TargetError struct {
+	Target
+	Err error
+}
+
+func (e *TargetError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Target, e.Err)
+}
+
+func (e *TargetError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError collects the outcome of an operation applied to several
+// targets, so callers can handle partial failure by retrying or reporting
+// only the failed targets. errors.Is and errors.As match against each of
+// the failures.
+type MultiError struct {
+	Succeeded []Target
+	Failed    []*TargetError
+}
+
+// Add records the outcome of the operation for a target, a nil error
+// marking it as succeeded
+func (e *MultiError) Add(target Target, err error) {
+	if err == nil {
+		e.Succeeded = append(e.Succeeded, target)
+		return
+	}
+
+	e.Failed = append(e.Failed, &TargetError{target, err})
+}
+
+// FailedTargets lists the targets of the failed operations
+func (e *MultiError) FailedTargets() []Target {
+	targets := make([]Target, 0, len(e.Failed))
+	for _, failed := range e.Failed {
+		targets = append(targets, failed.Target)
+	}
+
+	return targets
+}
+
+// ErrorOrNil returns the MultiError when any operation failed, otherwise nil
+func (e *MultiError) ErrorOrNil() error {
+	if len(e.Failed) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e *MultiError) Error() string {
+	messages := make([]string, 0, len(e.Failed))
+	for _, failed := range e.Failed {
+		messages = append(messages, failed.Error())
+	}
+
+	total := len(e.Succeeded) + len(e.Failed)
+
+	return fmt.Sprintf("%d of %d operations failed: %s", len(e.Failed), total, strings.Join(messages, "; "))
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, failed := range e.Failed {
+		errs = append(errs, failed)
+	}
+
+	return errs
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// Config is the presenced YAML configuration file
+type Config struct {
+	Router struct {
+		Host     string `yaml:"host"`
+		Port     int    `yaml:"port"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+	} `yaml:"router"`
+
+	PollInterval time.Duration `yaml:"poll_interval"`
+	StateFile    string        `yaml:"state_file"`
+	MACFormat    string        `yaml:"mac_format"`
+
+	// Devices attaches owners, tags, and notes to devices by MAC address
+	Devices map[string]netgear.DeviceMeta `yaml:"devices"`
+
+	MQTT struct {
+		Broker string `yaml:"broker"`
+
+		// ClientID must be unique among the brokers clients, a unique ID is
+		// generated when empty
+		ClientID    string `yaml:"client_id"`
+		Username    string `yaml:"username"`
+		Password    string `yaml:"password"`
+		TopicPrefix string `yaml:"topic_prefix"`
+	} `yaml:"mqtt"`
+
+	Webhooks []struct {
+		URL string `yaml:"url"`
+
+		// Format is json, cloudevents, or cloudevents-binary
+		Format string `yaml:"format"`
+	} `yaml:"webhooks"`
+
+	Privacy struct {
+		Key        string `yaml:"key"`
+		NameLength int    `yaml:"name_length"`
+	} `yaml:"privacy"`
+
+	Metrics struct {
+		Listen string `yaml:"listen"`
+
+		// SnapshotFile persists counters and presence sessions every
+		// SnapshotInterval and on shutdown, so they continue after a restart
+		// or crash
+		SnapshotFile     string        `yaml:"snapshot_file"`
+		SnapshotInterval time.Duration `yaml:"snapshot_interval"`
+	} `yaml:"metrics"`
+}
+
+func loadConfig(path string) (*Config, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	config.Router.Host = "192.168.1.1"
+	config.Router.Port = 5000
+	config.Router.Username = "admin"
+	config.PollInterval = 10 * time.Second
+	config.MACFormat = "colon"
+	config.MQTT.TopicPrefix = "netgear/presence"
+	config.Metrics.SnapshotInterval = 5 * time.Minute
+
+	if err := yaml.Unmarshal(contents, config); err != nil {
+		return nil, err
+	}
+
+	if config.Metrics.SnapshotInterval <= 0 {
+		return nil, fmt.Errorf("Metrics snapshot_interval must be positive")
+	}
+
+	return config, nil
+}
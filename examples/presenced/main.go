@@ -0,0 +1,165 @@
+// Command presenced is a reference presence daemon. It watches the devices
+// attached to a netgear router and publishes arrivals and departures to MQTT
+// and webhooks, while exposing router metrics to prometheus. Everything is
+// configured through a single YAML file, see config.example.yaml.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgearprom"
+	"go.evanpurkhiser.com/netgear/netgearsink"
+)
+
+var configPath = flag.String("config", "presenced.yaml", "Path to the presenced config file")
+
+func main() {
+	flag.Parse()
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Unable to load config: %s", err)
+	}
+
+	state, err := loadState(config.StateFile)
+	if err != nil {
+		log.Fatalf("Unable to load state: %s", err)
+	}
+
+	macFormat, err := netgear.ParseMACFormat(config.MACFormat)
+	if err != nil {
+		log.Fatalf("Invalid config: %s", err)
+	}
+
+	opts := []netgear.ClientOption{netgear.WithMACFormat(macFormat)}
+	if config.Privacy.Key != "" {
+		opts = append(opts, netgear.WithPrivacy(&netgear.Privacy{
+			Key:        []byte(config.Privacy.Key),
+			NameLength: config.Privacy.NameLength,
+		}))
+	}
+
+	client := netgear.NewClient(config.Router.Host, config.Router.Username, config.Router.Password, opts...)
+	client.Port = config.Router.Port
+
+	// Detect a misconfigured router before the first poll
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	report := client.SelfTest(ctx)
+	cancel()
+
+	for _, step := range report.Steps {
+		if step.Warning != "" {
+			log.Printf("Self test %s: %s", step.Name, step.Warning)
+		}
+	}
+	if err := report.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	traffic := netgearprom.NewTrafficCollector()
+
+	metrics, err := loadMetrics(config.Metrics.SnapshotFile, traffic)
+	if err != nil {
+		log.Fatalf("Unable to load metrics snapshot: %s", err)
+	}
+
+	sinks := []sink{}
+
+	if config.MQTT.Broker != "" {
+		mqttSink, err := newMQTTSink(config)
+		if err != nil {
+			log.Fatalf("Unable to connect to MQTT broker: %s", err)
+		}
+		sinks = append(sinks, mqttSink)
+	}
+
+	for _, hook := range config.Webhooks {
+		if !netgearsink.ValidFormat(hook.Format) {
+			log.Fatalf("Invalid config: unknown webhook format %q", hook.Format)
+		}
+		sinks = append(sinks, &webhookSink{&netgearsink.Webhook{Client: client, URL: hook.URL, Format: hook.Format}})
+	}
+
+	if config.Metrics.Listen != "" {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(netgearprom.NewCollector(client), traffic, metrics)
+
+		http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		go func() {
+			log.Fatal(http.ListenAndServe(config.Metrics.Listen, nil))
+		}()
+	}
+
+	listener := func(change *netgear.ChangedDevice, err error) {
+		if err != nil {
+			log.Printf("Failed to query for devices: %s", err)
+			return
+		}
+
+		log.Printf("Device %s: %s (%s)", change.Change, client.FormatMAC(change.Device.MAC), client.FormatLabel(change.Device))
+
+		if err := state.Apply(change); err != nil {
+			log.Printf("Unable to save state: %s", err)
+		}
+
+		e := newEvent(client, change)
+
+		sequence, session := metrics.record(e.MAC, change.Change)
+		e.Sequence = sequence
+		e.SessionSeconds = session.Seconds()
+
+		for _, s := range sinks {
+			if err := s.Send(change, e); err != nil {
+				log.Printf("Unable to publish change: %s", err)
+			}
+		}
+	}
+
+	client.Watch(
+		config.PollInterval,
+		listener,
+		netgear.WithKnownDevices(state.Devices()),
+		netgear.WithDeviceMeta(config.Devices),
+	)
+
+	client.WatchTraffic(config.PollInterval, traffic.Listener())
+
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+
+	// Save the snapshot periodically so a crash loses little
+	snapshots := time.NewTicker(config.Metrics.SnapshotInterval)
+	defer snapshots.Stop()
+
+	for running := true; running; {
+		select {
+		case <-snapshots.C:
+			if err := metrics.save(); err != nil {
+				log.Printf("Unable to save metrics snapshot: %s", err)
+			}
+		case <-shutdown:
+			running = false
+		}
+	}
+
+	// Closing the client stops the watchers and ends the router session, so
+	// the snapshot saved after includes every reported change
+	if err := client.Close(); err != nil {
+		log.Printf("Unable to close the router session: %s", err)
+	}
+
+	if err := metrics.save(); err != nil {
+		log.Fatalf("Unable to save metrics snapshot: %s", err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgearprom"
+)
+
+// snapshot is the metrics state persisted across restarts, so counters keep
+// increasing and presence sessions in progress keep their arrival time
+type snapshot struct {
+	Traffic  netgearprom.TrafficSnapshot `json:"traffic"`
+	Sequence uint64                      `json:"sequence"`
+	Arrived  map[string]time.Time        `json:"arrived"`
+	SavedAt  time.Time                   `json:"saved_at"`
+}
+
+// metrics numbers each published change and tracks presence sessions,
+// exporting both to prometheus
+type metrics struct {
+	path    string
+	traffic *netgearprom.TrafficCollector
+
+	events  *prometheus.Desc
+	session *prometheus.Desc
+
+	mu       sync.Mutex
+	sequence uint64
+	arrived  map[string]time.Time
+}
+
+// loadMetrics restores the metrics snapshot at path, when one has been saved
+func loadMetrics(path string, traffic *netgearprom.TrafficCollector) (*metrics, error) {
+	m := &metrics{
+		path:    path,
+		traffic: traffic,
+		arrived: map[string]time.Time{},
+		events: prometheus.NewDesc(
+			"presenced_events_total",
+			"Number of device changes published.",
+			nil, nil,
+		),
+		session: prometheus.NewDesc(
+			"presenced_device_session_seconds",
+			"Time since an attached device arrived.",
+			[]string{"mac"}, nil,
+		),
+	}
+
+	if path == "" {
+		return m, nil
+	}
+
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := snapshot{}
+	if err := json.Unmarshal(contents, &s); err != nil {
+		return nil, err
+	}
+
+	m.sequence = s.Sequence
+	if s.Arrived != nil {
+		m.arrived = s.Arrived
+	}
+	traffic.Restore(s.Traffic)
+
+	return m, nil
+}
+
+// record numbers a change and tracks the presence session of the device,
+// returning the duration of the session when the device left. Devices are
+// keyed by their formatted MAC address, as exported.
+func (m *metrics) record(mac string, change netgear.DeviceChange) (sequence uint64, session time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sequence++
+
+	switch change {
+	case netgear.DeviceAdded:
+		m.arrived[mac] = time.Now()
+	case netgear.DeviceRemoved:
+		if arrived, ok := m.arrived[mac]; ok {
+			session = time.Since(arrived)
+		}
+		delete(m.arrived, mac)
+	}
+
+	return m.sequence, session
+}
+
+// save writes the metrics snapshot, when a path is configured
+func (m *metrics) save() error {
+	if m.path == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	s := snapshot{
+		Traffic:  m.traffic.Snapshot(),
+		Sequence: m.sequence,
+		Arrived:  m.arrived,
+		SavedAt:  time.Now(),
+	}
+	contents, err := json.Marshal(s)
+	m.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(m.path, contents, 0o644)
+}
+
+// Describe implements prometheus.Collector
+func (m *metrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.events
+	ch <- m.session
+}
+
+// Collect implements prometheus.Collector
+func (m *metrics) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(m.events, prometheus.CounterValue, float64(m.sequence))
+
+	for mac, arrived := range m.arrived {
+		ch <- prometheus.MustNewConstMetric(m.session, prometheus.GaugeValue, time.Since(arrived).Seconds(), mac)
+	}
+}
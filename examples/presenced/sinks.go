@@ -0,0 +1,67 @@
+package main
+
+import (
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgearsink"
+)
+
+// sink receives device changes
+type sink interface {
+	Send(change *netgear.ChangedDevice, e event) error
+}
+
+type event struct {
+	netgearsink.Event
+
+	// Sequence numbers each change, continuing across restarts when a
+	// metrics snapshot file is configured
+	Sequence uint64 `json:"sequence"`
+
+	// SessionSeconds is how long a removed device was attached, when its
+	// arrival was seen
+	SessionSeconds float64 `json:"session_seconds,omitempty"`
+}
+
+func newEvent(client *netgear.Client, change *netgear.ChangedDevice) event {
+	return event{Event: netgearsink.NewEvent(client, change)}
+}
+
+// webhookSink posts each change to a URL, either as plain JSON or as a
+// CloudEvent in structured or binary mode
+type webhookSink struct {
+	hook *netgearsink.Webhook
+}
+
+func (s *webhookSink) Send(change *netgear.ChangedDevice, e event) error {
+	return s.hook.Post(e.Change, e.MAC, e)
+}
+
+// mqttSink publishes a retained presence state per device, along with each
+// change as an event
+type mqttSink struct {
+	mqtt *netgearsink.MQTT
+}
+
+func newMQTTSink(config *Config) (*mqttSink, error) {
+	m := &netgearsink.MQTT{
+		Broker:      config.MQTT.Broker,
+		ClientID:    config.MQTT.ClientID,
+		Username:    config.MQTT.Username,
+		Password:    config.MQTT.Password,
+		TopicPrefix: config.MQTT.TopicPrefix,
+	}
+
+	if err := m.Connect(); err != nil {
+		return nil, err
+	}
+
+	return &mqttSink{mqtt: m}, nil
+}
+
+func (s *mqttSink) Send(change *netgear.ChangedDevice, e event) error {
+	if err := s.mqtt.PublishState(e.MAC, change.Change != netgear.DeviceRemoved); err != nil {
+		return err
+	}
+
+	return s.mqtt.PublishEvent(e)
+}
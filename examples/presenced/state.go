@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// stateStore persists the attached devices between restarts, so devices that
+// were already present are not reported as arriving again.
+type stateStore struct {
+	path    string
+	devices map[string]netgear.AttachedDevice
+}
+
+func loadState(path string) (*stateStore, error) {
+	store := &stateStore{path: path, devices: map[string]netgear.AttachedDevice{}}
+
+	if path == "" {
+		return store, nil
+	}
+
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	devices := []netgear.AttachedDevice{}
+	if err := json.Unmarshal(contents, &devices); err != nil {
+		return nil, err
+	}
+
+	for _, dev := range devices {
+		store.devices[dev.MAC.String()] = dev
+	}
+
+	return store, nil
+}
+
+func (s *stateStore) Devices() []netgear.AttachedDevice {
+	devices := make([]netgear.AttachedDevice, 0, len(s.devices))
+	for _, dev := range s.devices {
+		devices = append(devices, dev)
+	}
+
+	return devices
+}
+
+func (s *stateStore) Apply(change *netgear.ChangedDevice) error {
+	mac := change.Device.MAC.String()
+
+	switch change.Change {
+	case netgear.DeviceAdded:
+		s.devices[mac] = change.Device
+	case netgear.DeviceRemoved:
+		delete(s.devices, mac)
+	}
+
+	if s.path == "" {
+		return nil
+	}
+
+	contents, err := json.Marshal(s.Devices())
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path, contents, 0o644)
+}
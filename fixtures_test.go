@@ -0,0 +1,183 @@
+package netgear_test
+
+import (
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+// fixtureChecks parse a served fixture with the client API wrapping its
+// action, checking the parsed values are consistent with the recording
+var fixtureChecks = map[string]func(t *testing.T, client *netgear.Client, f netgeartest.Fixture){
+	soapconst.DeviceInfoGetInfo: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		info, err := client.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Model != f.Model || info.Firmware != f.Firmware {
+			t.Errorf("Expected %s %s, got %s %s", f.Model, f.Firmware, info.Model, info.Firmware)
+		}
+	},
+	soapconst.DeviceInfoGetAttachDevice: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		list, err := client.DeviceList()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !list.Complete() || len(list.Devices) == 0 {
+			t.Errorf("Expected a complete device list, %d reported and %d listed", list.Reported, list.Listed())
+		}
+
+		checkFixtureDevices(t, list.Devices)
+	},
+	soapconst.DeviceInfoGetAttachDevice2: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		devices, err := client.DetailedDevices()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(devices) == 0 {
+			t.Error("Expected detailed devices")
+		}
+
+		checkFixtureDevices(t, devices)
+	},
+	soapconst.DeviceInfoGetSupportFeatureListXML: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		features, err := client.Features()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(features) == 0 {
+			t.Error("Expected features")
+		}
+
+		for name, version := range features {
+			if version == "" {
+				t.Errorf("Feature %s has no version", name)
+			}
+		}
+	},
+	soapconst.DeviceConfigGetTrafficMeterOptions: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		options, err := client.TrafficMeterOptions()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if options.RestartDay < 1 || options.RestartHour > 23 || options.RestartMinute > 59 {
+			t.Errorf("Unexpected restart time %+v", options)
+		}
+	},
+	soapconst.DeviceConfigGetTrafficMeterStatistics: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		meter, err := client.TrafficMeter()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if meter.Month.Download < meter.Today.Download || meter.Month.Upload < meter.Today.Upload {
+			t.Errorf("Expected the month to include today, got %+v", meter)
+		}
+	},
+	soapconst.WANIPConnectionGetInfo: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		wan, err := client.WAN()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wan.ExternalIP == nil || wan.Gateway == nil || len(wan.DNSServers) == 0 {
+			t.Errorf("Expected WAN addresses, got %+v", wan)
+		}
+	},
+	soapconst.WLANConfigurationGetInfo: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		checkFixtureWireless(t, client, netgear.Band2G)
+	},
+	soapconst.WLANConfigurationGet5GInfo: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		checkFixtureWireless(t, client, netgear.Band5G)
+	},
+	soapconst.WLANConfigurationGetGuestAccessEnabled: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		network, err := client.GuestNetwork(netgear.Band2G)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !network.Enabled {
+			t.Error("Expected the guest network to be enabled")
+		}
+	},
+	soapconst.WLANConfigurationGetGuestAccessNetworkInfo: func(t *testing.T, client *netgear.Client, f netgeartest.Fixture) {
+		network, err := client.GuestNetwork(netgear.Band2G)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if network.SSID != "redacted" {
+			t.Errorf("Expected the sanitized SSID, got %q", network.SSID)
+		}
+	},
+}
+
+func checkFixtureDevices(t *testing.T, devices []netgear.AttachedDevice) {
+	t.Helper()
+
+	for _, dev := range devices {
+		if dev.MAC == nil || dev.IP == nil {
+			t.Errorf("Device is missing its MAC or IP: %+v", dev)
+		}
+
+		if dev.Type != "wired" && dev.Type != "wireless" {
+			t.Errorf("Device %s has unknown type %q", dev.MAC, dev.Type)
+		}
+	}
+}
+
+func checkFixtureWireless(t *testing.T, client *netgear.Client, band netgear.Band) {
+	t.Helper()
+
+	info, err := client.WirelessInfo(band)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.Enabled || info.Channel == "" || info.Security == "" {
+		t.Errorf("Expected an enabled and configured band, got %+v", info)
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	fixtures, err := netgeartest.LoadFixtures("netgeartest/fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fixtures) == 0 {
+		t.Fatal("Expected fixtures in the corpus")
+	}
+
+	for _, f := range fixtures {
+		t.Run(f.Filename(), func(t *testing.T) {
+			check, ok := fixtureChecks[f.Action]
+			if !ok {
+				t.Fatalf("No check for fixture action %s", f.Action)
+			}
+
+			// APIs calling several actions are answered by the fixtures
+			// recorded from the same router
+			server := netgeartest.Start(t)
+			for _, recorded := range fixtures {
+				if recorded.Model == f.Model && recorded.Firmware == f.Firmware {
+					server.ServeFixture(recorded)
+				}
+			}
+
+			client := server.Client()
+			if err := client.Login(); err != nil {
+				t.Fatal(err)
+			}
+
+			check(t, client, f)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package netgear
+
+import (
+	"time"
+)
+
+// forecastWarmup is how long into the month the usage rate is blended with
+// last months rate, since the first few days alone are a poor predictor
+const forecastWarmup = 3 * 24 * time.Hour
+
+// UsageForecast projects the data usage of the current traffic meter month.
+// Volumes are in megabytes.
+type UsageForecast struct {
+	// Start and End are the bounds of the traffic meter month, End being
+	// when the monthly counters next restart
+	Start time.Time
+	End   time.Time
+	At    time.Time
+
+	// Used is the volume counted toward the monthly limit so far, which is
+	// only the download volume when the limit applies to downloads only
+	Used      float64
+	Projected float64
+
+	// DailyRate is the projected usage per day for the rest of the month
+	DailyRate float64
+
+	// Limit is the monthly data limit, zero when there is none
+	Limit float64
+
+	// CapAt is when the usage is projected to reach the limit, zero when it
+	// is not projected to be reached this month
+	CapAt time.Time
+}
+
+// OverCap reports if the usage has reached or is projected to exceed the
+// limit this month
+func (f *UsageForecast) OverCap() bool {
+	return !f.CapAt.IsZero()
+}
+
+// DaysUntilCap is the number of days until the usage is projected to reach
+// the limit. False is returned when the limit is not projected to be reached
+// this month.
+func (f *UsageForecast) DaysUntilCap() (float64, bool) {
+	if f.CapAt.IsZero() {
+		return 0, false
+	}
+
+	return f.CapAt.Sub(f.At).Hours() / 24, true
+}
+
+// ForecastUsage projects the usage of the current traffic meter month from
+// the recorded traffic. The rate of the month so far is used, blended with
+// last months rate during the first few days of the month.
+func ForecastUsage(meter *TrafficMeter, options *TrafficMeterOptions) *UsageForecast {
+	start, now := meter.Month.Start, meter.Month.End
+	end := trafficMonthRestart(options, start.Year(), start.Month()+1, start.Location())
+
+	f := &UsageForecast{
+		Start: start,
+		End:   end,
+		At:    now,
+		Used:  countedUsage(meter.Month, options),
+	}
+
+	if options.ControlOption != "No limit" {
+		f.Limit = options.MonthlyLimit
+	}
+
+	elapsed := now.Sub(start)
+	if elapsed > 0 {
+		f.DailyRate = f.Used / elapsed.Hours() * 24
+	}
+
+	lastMonth := meter.LastMonth.End.Sub(meter.LastMonth.Start)
+	lastUsed := countedUsage(meter.LastMonth, options)
+
+	if elapsed < forecastWarmup && lastMonth > 0 && lastUsed > 0 {
+		weight := float64(elapsed) / float64(forecastWarmup)
+		lastRate := lastUsed / lastMonth.Hours() * 24
+		f.DailyRate = weight*f.DailyRate + (1-weight)*lastRate
+	}
+
+	remaining := end.Sub(now).Hours() / 24
+	f.Projected = f.Used + f.DailyRate*remaining
+
+	switch {
+	case f.Limit <= 0:
+	case f.Used >= f.Limit:
+		f.CapAt = now
+	case f.Projected <= f.Limit:
+	default:
+		days := (f.Limit - f.Used) / f.DailyRate
+		f.CapAt = now.Add(time.Duration(days * 24 * float64(time.Hour)))
+	}
+
+	return f
+}
+
+// countedUsage is the volume of a period counted toward the monthly limit
+func countedUsage(p TrafficPeriod, options *TrafficMeterOptions) float64 {
+	if options.ControlOption == "Download only" {
+		return p.Download
+	}
+
+	return p.Upload + p.Download
+}
+
+// ProjectedMonthlyUsage forecasts the data usage of the current traffic meter
+// month, see ForecastUsage
+func (c *Client) ProjectedMonthlyUsage() (*UsageForecast, error) {
+	options, err := c.TrafficMeterOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	meter, err := c.trafficMeter(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return ForecastUsage(meter, options), nil
+}
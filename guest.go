@@ -0,0 +1,237 @@
+package netgear
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+// guestActions are the actions managing the guest network of a band. Newer
+// firmware only accepts the second version of the set actions.
+type guestActions struct {
+	enabled soapAction
+	info    soapAction
+	set     []soapAction
+}
+
+var guestNetworkActions = map[Band]guestActions{
+	Band2G: {
+		enabled: soapconst.WLANConfigurationGetGuestAccessEnabled,
+		info:    soapconst.WLANConfigurationGetGuestAccessNetworkInfo,
+		set: []soapAction{
+			soapconst.WLANConfigurationSetGuestAccessEnabled2,
+			soapconst.WLANConfigurationSetGuestAccessEnabled,
+		},
+	},
+	Band5G: {
+		enabled: soapconst.WLANConfigurationGet5GGuestAccessEnabled,
+		info:    soapconst.WLANConfigurationGet5GGuestAccessNetworkInfo,
+		set: []soapAction{
+			soapconst.WLANConfigurationSet5GGuestAccessEnabled2,
+			soapconst.WLANConfigurationSet5GGuestAccessEnabled,
+		},
+	},
+}
+
+// GuestNetwork describes the guest network of a wireless band
+type GuestNetwork struct {
+	Band    Band
+	Enabled bool
+	SSID    string
+}
+
+// GuestNetwork gets the guest network of a wireless band
+func (c *Client) GuestNetwork(band Band) (*GuestNetwork, error) {
+	actions, ok := guestNetworkActions[band]
+	if !ok {
+		return nil, fmt.Errorf("Unknown wireless band %q", band)
+	}
+
+	type soapEnabledEnvelope struct {
+		Body struct {
+			Enabled   string `xml:"GetGuestAccessEnabledResponse>NewGuestAccessEnabled"`
+			Enabled5G string `xml:"Get5GGuestAccessEnabledResponse>NewGuestAccessEnabled"`
+		} `xml:"Body"`
+	}
+
+	enabled := soapEnabledEnvelope{}
+	if err := c.call("get guest access", actions.enabled, nil, &enabled); err != nil {
+		return nil, err
+	}
+
+	type soapInfoEnvelope struct {
+		Body struct {
+			SSID   string `xml:"GetGuestAccessNetworkInfoResponse>NewSSID"`
+			SSID5G string `xml:"Get5GGuestAccessNetworkInfoResponse>NewSSID"`
+		} `xml:"Body"`
+	}
+
+	info := soapInfoEnvelope{}
+	if err := c.call("get guest network", actions.info, nil, &info); err != nil {
+		return nil, err
+	}
+
+	network := &GuestNetwork{
+		Band:    band,
+		Enabled: enabled.Body.Enabled == "1",
+		SSID:    info.Body.SSID,
+	}
+
+	if band == Band5G {
+		network.Enabled = enabled.Body.Enabled5G == "1"
+		network.SSID = info.Body.SSID5G
+	}
+
+	return network, nil
+}
+
+// SetGuestAccess enables or disables the guest network of a wireless band
+func (c *Client) SetGuestAccess(band Band, enabled bool) error {
+	return c.SetGuestAccessContext(context.Background(), band, enabled)
+}
+
+// SetGuestAccessContext is SetGuestAccess, joining the configuration
+// transaction of ctx when made within Configure
+func (c *Client) SetGuestAccessContext(ctx context.Context, band Band, enabled bool) error {
+	actions, ok := guestNetworkActions[band]
+	if !ok {
+		return fmt.Errorf("Unknown wireless band %q", band)
+	}
+
+	value := "0"
+	if enabled {
+		value = "1"
+	}
+
+	params := []SOAPParam{{"NewGuestAccessEnabled", value}}
+
+	return c.configure(ctx, func(ctx context.Context) error {
+		var err error
+
+		// Fall back to the older set action when the router does not
+		// implement the newer one
+		for _, action := range actions.set {
+			err = c.callContext(ctx, "set guest access", action, params, nil)
+
+			respErr := &ResponseError{}
+			if !errors.As(err, &respErr) || respErr.Code != codeNotSupported {
+				return err
+			}
+		}
+
+		return err
+	})
+}
+
+// WithGuestShutoff disables the guest network of each band once no devices
+// have been attached to it for the idle duration, calling fn after guest
+// access has been disabled or with the error when it could not be. Devices
+// are matched to the guest network by SSID, which is only reported by
+// detailed polls, so this should be combined with WithDetailedEvery. Before
+// a network is disabled after a cheaper poll, the detailed devices are
+// fetched to find guests which attached since the last detailed poll.
+func WithGuestShutoff(idle time.Duration, fn func(band Band, err error)) WatchOption {
+	return func(w *Watcher) {
+		w.guest = &guestShutoff{
+			idle:        idle,
+			notify:      fn,
+			ssids:       map[Band]string{},
+			lastSeen:    map[Band]time.Time{},
+			unsupported: map[Band]bool{},
+		}
+	}
+}
+
+// guestShutoff tracks when devices were last attached to each guest network
+type guestShutoff struct {
+	idle   time.Duration
+	notify func(band Band, err error)
+
+	ssids       map[Band]string
+	lastSeen    map[Band]time.Time
+	unsupported map[Band]bool
+}
+
+// observe checks the guest network of each band against the attached
+// devices of a poll, disabling networks which have been idle. The idle
+// duration starts from the first poll. Devices from polls which were not
+// detailed only have the SSID they had at the last detailed poll.
+func (g *guestShutoff) observe(c *Client, devices []AttachedDevice, detailed bool, now time.Time) {
+	for _, band := range []Band{Band2G, Band5G} {
+		if g.unsupported[band] {
+			continue
+		}
+
+		if _, ok := g.ssids[band]; !ok {
+			network, err := c.GuestNetwork(band)
+
+			respErr := &ResponseError{}
+			if errors.As(err, &respErr) && respErr.Code == codeNotSupported {
+				g.unsupported[band] = true
+				continue
+			}
+			if err != nil {
+				g.notify(band, err)
+				continue
+			}
+
+			g.ssids[band] = network.SSID
+			g.lastSeen[band] = now
+		}
+
+		if guestAttached(devices, g.ssids[band]) {
+			g.lastSeen[band] = now
+			continue
+		}
+
+		if now.Sub(g.lastSeen[band]) < g.idle {
+			continue
+		}
+
+		// Check again after another idle duration, whatever the outcome
+		g.lastSeen[band] = now
+
+		// The guest network may have been reconfigured since it was looked up
+		network, err := c.GuestNetwork(band)
+		if err != nil {
+			g.notify(band, err)
+			continue
+		}
+		g.ssids[band] = network.SSID
+
+		// Guests which attached since the last detailed poll have no SSID
+		// yet, resolve them before disabling the network under them
+		if !detailed {
+			if devices, err = c.DetailedDevices(); err != nil {
+				g.notify(band, err)
+				continue
+			}
+			detailed = true
+		}
+
+		if !network.Enabled || guestAttached(devices, network.SSID) {
+			continue
+		}
+
+		g.notify(band, c.SetGuestAccess(band, false))
+	}
+}
+
+// guestAttached checks if any device is attached to the guest SSID. Without
+// a known SSID devices are assumed attached, so the network is left alone.
+func guestAttached(devices []AttachedDevice, ssid string) bool {
+	if ssid == "" {
+		return true
+	}
+
+	for _, dev := range devices {
+		if dev.SSID == ssid {
+			return true
+		}
+	}
+
+	return false
+}
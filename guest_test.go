@@ -0,0 +1,62 @@
+package netgear_test
+
+import (
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func TestGuestShutoffResolvesSSID(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetGuestNetwork(netgear.GuestNetwork{Band: netgear.Band2G, Enabled: true, SSID: "guests"})
+
+	disabled := make(chan error, 1)
+	shutoff := netgear.WithGuestShutoff(time.Nanosecond, func(band netgear.Band, err error) {
+		disabled <- err
+	})
+
+	// Only the first poll is detailed
+	watcher, recorder := watch(t, server.Client(), shutoff, netgear.WithDetailedEvery(100))
+
+	watcher.PollNow()
+	expectQuiet(t, recorder)
+
+	// A guest attaching between detailed polls has no SSID in the poll
+	guest := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "guest")
+	guest.SSID = "guests"
+	server.SetDevices(guest)
+
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:01")
+
+	select {
+	case err := <-disabled:
+		t.Fatalf("Expected the guest network to stay enabled with a guest attached, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if !server.GuestNetwork(netgear.Band2G).Enabled {
+		t.Fatal("Expected the guest network to stay enabled")
+	}
+
+	// Once the guest leaves the network is disabled
+	server.SetDevices()
+
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceRemoved, "aa:bb:cc:00:00:01")
+
+	select {
+	case err := <-disabled:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(waitTimeout):
+		t.Fatal("Expected the idle guest network to be disabled")
+	}
+
+	if server.GuestNetwork(netgear.Band2G).Enabled {
+		t.Error("Expected the guest network to be disabled")
+	}
+}
@@ -0,0 +1,35 @@
+package netgear
+
+import "time"
+
+// PollStats describes a completed poll cycle of a Watcher
+type PollStats struct {
+	// Poll is the number of the poll, starting from zero
+	Poll     int
+	Detailed bool
+	Started  time.Time
+	Duration time.Duration
+
+	// Devices is the number of attached devices and Changes the number of
+	// changes reported, both are zero when the poll failed
+	Devices int
+	Changes int
+	Err     error
+}
+
+// WithBeforePoll calls fn before each poll cycle. Returning false skips the
+// cycle, for example while another instance holds leadership or during a
+// maintenance window. Skipped cycles are not counted as polls.
+func WithBeforePoll(fn func(poll int) bool) WatchOption {
+	return func(w *Watcher) {
+		w.beforePoll = fn
+	}
+}
+
+// WithAfterPoll calls fn after each poll cycle, once changes have been
+// reported to the listeners
+func WithAfterPoll(fn func(PollStats)) WatchOption {
+	return func(w *Watcher) {
+		w.afterPoll = fn
+	}
+}
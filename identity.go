@@ -0,0 +1,53 @@
+package netgear
+
+import "strings"
+
+// IdentityFunc determines the identity a device is tracked by. Devices with
+// the same identity are considered the same device, even when their MAC
+// address differs.
+type IdentityFunc func(AttachedDevice) string
+
+// IdentityMAC identifies devices by MAC address. This is the default.
+func IdentityMAC(dev AttachedDevice) string {
+	return dev.MAC.String()
+}
+
+// IdentityHostname identifies devices by their case insensitive hostname,
+// so a phone rotating its randomized MAC address or a laptop moved between
+// docking stations is tracked as one device. Devices without a hostname are
+// identified by MAC address.
+func IdentityHostname(dev AttachedDevice) string {
+	if dev.Name == "" {
+		return IdentityMAC(dev)
+	}
+
+	return "host:" + strings.ToLower(dev.Name)
+}
+
+// IdentityMACSSID identifies devices by MAC address and the SSID they are
+// connected to, so a cloned MAC address seen on separate networks is tracked
+// as separate devices. The SSID is a detailed field, see WithDetailedEvery.
+func IdentityMACSSID(dev AttachedDevice) string {
+	return IdentityMAC(dev) + "/" + dev.SSID
+}
+
+// WithIdentity tracks devices by the identity determined by fn instead of by
+// MAC address. Added and removed changes are reported when an identity
+// attaches or detaches, with the device being the most recently seen device
+// of that identity. When several attached devices share an identity only the
+// first listed by the router is tracked.
+func WithIdentity(fn IdentityFunc) WatchOption {
+	return func(w *Watcher) {
+		w.identity = fn
+	}
+}
+
+// key is the identity the watcher tracks a device by. Without an identity
+// function the raw MAC address is used, which avoids formatting it each poll.
+func (w *Watcher) key(dev AttachedDevice) string {
+	if w.identity == nil {
+		return string(dev.MAC)
+	}
+
+	return w.identity(dev)
+}
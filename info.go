@@ -0,0 +1,31 @@
+package netgear
+
+import "go.evanpurkhiser.com/netgear/soapconst"
+
+const infoAction soapAction = soapconst.DeviceInfoGetInfo
+
+// RouterInfo describes the router model and firmware
+type RouterInfo struct {
+	Model        string `xml:"ModelName"`
+	Description  string `xml:"Description"`
+	DeviceName   string `xml:"DeviceName"`
+	SerialNumber string `xml:"SerialNumber"`
+	Firmware     string `xml:"Firmwareversion"`
+	Hardware     string `xml:"Hardwareversion"`
+}
+
+// Info gets the routers model and firmware information
+func (c *Client) Info() (*RouterInfo, error) {
+	type soapEnvelope struct {
+		Info RouterInfo `xml:"Body>GetInfoResponse"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := c.call("get router info", infoAction, nil, &envelope); err != nil {
+		return nil, err
+	}
+
+	c.learnModel(envelope.Info.Model)
+
+	return &envelope.Info, nil
+}
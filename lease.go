@@ -0,0 +1,219 @@
+package netgear
+
+import (
+	"bytes"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLeaseTime is the DHCP lease time used by netgear firmware unless
+// configured otherwise
+const DefaultLeaseTime = 24 * time.Hour
+
+// DeviceState classifies a device by whether it is attached and whether it
+// still holds a DHCP lease
+type DeviceState string
+
+// Device states
+const (
+	// StateOnline devices are attached to the router
+	StateOnline DeviceState = "online"
+
+	// StateSleeping devices are not attached but still hold an active DHCP
+	// lease, such as a phone which has dropped off the network while idle
+	StateSleeping DeviceState = "sleeping"
+
+	// StateGone devices are not attached and their DHCP lease has expired,
+	// or no lease is known for them
+	StateGone DeviceState = "gone"
+)
+
+// Lease is a DHCP lease held by a device
+type Lease struct {
+	MAC     net.HardwareAddr
+	IP      net.IP
+	Granted time.Time
+	Expires time.Time
+}
+
+// LeaseTracker classifies devices as online, sleeping, or gone. The router
+// does not expose its DHCP lease table over SOAP, so leases are learned from
+// the DHCP entries of the router log, provided using AddLog, and from devices
+// seen attached. A device renews its lease at half the lease time, so a
+// device leaving is assumed to hold its lease for at least that long.
+type LeaseTracker struct {
+	// LeaseTime is the DHCP lease time configured on the router
+	LeaseTime time.Duration
+
+	// OnStateChange is called when the state of a device changes, including
+	// when a lease expires
+	OnStateChange func(mac net.HardwareAddr, state DeviceState)
+
+	mu       sync.Mutex
+	leases   map[string]*Lease
+	attached map[string]bool
+	expiry   map[string]*time.Timer
+
+	// reported is the last state reported for each device, devices which
+	// are gone are not kept
+	reported map[string]DeviceState
+}
+
+// NewLeaseTracker constructs a LeaseTracker for a router using the given
+// lease time, DefaultLeaseTime when zero
+func NewLeaseTracker(leaseTime time.Duration) *LeaseTracker {
+	if leaseTime == 0 {
+		leaseTime = DefaultLeaseTime
+	}
+
+	return &LeaseTracker{
+		LeaseTime: leaseTime,
+		leases:    map[string]*Lease{},
+		attached:  map[string]bool{},
+		expiry:    map[string]*time.Timer{},
+		reported:  map[string]DeviceState{},
+	}
+}
+
+// Listener is the DeviceListener tracking which devices are attached.
+// Attach it to a Watcher using Subscribe with replay enabled.
+func (t *LeaseTracker) Listener() DeviceListener {
+	return func(change *ChangedDevice, err error) {
+		if err != nil {
+			return
+		}
+
+		dev := change.Device
+		now := time.Now()
+
+		switch change.Change {
+		case DeviceAdded, DeviceSeen:
+			t.update(dev.MAC, func() {
+				t.attached[dev.MAC.String()] = true
+				t.grant(dev.MAC, dev.IP, now, now.Add(t.LeaseTime))
+			})
+		case DeviceRemoved:
+			t.update(dev.MAC, func() {
+				delete(t.attached, dev.MAC.String())
+				t.grant(dev.MAC, dev.IP, time.Time{}, now.Add(t.LeaseTime/2))
+			})
+		}
+	}
+}
+
+// AddLog adds a router log entry, DHCP entries granting a lease are tracked
+// and all others are ignored
+func (t *LeaseTracker) AddLog(entry LogEntry) {
+	if entry.Kind != "DHCP IP" || entry.MAC == nil {
+		return
+	}
+
+	t.update(entry.MAC, func() {
+		t.grant(entry.MAC, entry.IP, entry.Time, entry.Time.Add(t.LeaseTime))
+	})
+}
+
+// grant records a lease, extending any existing lease for the device. Must be
+// called with the lock held.
+func (t *LeaseTracker) grant(mac net.HardwareAddr, ip net.IP, granted, expires time.Time) {
+	lease, ok := t.leases[mac.String()]
+	if !ok {
+		lease = &Lease{MAC: mac}
+		t.leases[mac.String()] = lease
+	}
+
+	if ip != nil {
+		lease.IP = ip
+	}
+	if granted.After(lease.Granted) {
+		lease.Granted = granted
+	}
+	if expires.After(lease.Expires) {
+		lease.Expires = expires
+	}
+}
+
+// update applies fn to the tracked state of a device, reporting the state
+// change and scheduling the lease expiry
+func (t *LeaseTracker) update(mac net.HardwareAddr, fn func()) {
+	t.mu.Lock()
+
+	fn()
+
+	key := mac.String()
+	after := t.state(mac, time.Now())
+
+	before, ok := t.reported[key]
+	if !ok {
+		before = StateGone
+	}
+
+	if timer, ok := t.expiry[key]; ok {
+		timer.Stop()
+		delete(t.expiry, key)
+	}
+
+	switch after {
+	case StateSleeping:
+		lease := t.leases[key]
+		t.expiry[key] = time.AfterFunc(time.Until(lease.Expires), func() {
+			t.update(mac, func() {})
+		})
+		t.reported[key] = after
+	case StateOnline:
+		t.reported[key] = after
+	case StateGone:
+		delete(t.leases, key)
+		delete(t.reported, key)
+	}
+
+	t.mu.Unlock()
+
+	if before != after && t.OnStateChange != nil {
+		t.OnStateChange(mac, after)
+	}
+}
+
+// state classifies a device. Must be called with the lock held.
+func (t *LeaseTracker) state(mac net.HardwareAddr, now time.Time) DeviceState {
+	if t.attached[mac.String()] {
+		return StateOnline
+	}
+
+	if lease, ok := t.leases[mac.String()]; ok && lease.Expires.After(now) {
+		return StateSleeping
+	}
+
+	return StateGone
+}
+
+// State classifies a device as online, sleeping, or gone
+func (t *LeaseTracker) State(mac net.HardwareAddr) DeviceState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.state(mac, time.Now())
+}
+
+// Leases lists the leases which have not expired, ordered by MAC address
+func (t *LeaseTracker) Leases() []Lease {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	leases := []Lease{}
+
+	for _, lease := range t.leases {
+		if lease.Expires.After(now) {
+			leases = append(leases, *lease)
+		}
+	}
+
+	sort.Slice(leases, func(i, j int) bool {
+		return bytes.Compare(leases[i].MAC, leases[j].MAC) < 0
+	})
+
+	return leases
+}
@@ -1,6 +1,10 @@
 package netgear
 
-import "time"
+import (
+	"bytes"
+	"sync"
+	"time"
+)
 
 // DeviceChange repressents the change in the devices status
 type DeviceChange string
@@ -9,6 +13,14 @@ type DeviceChange string
 const (
 	DeviceAdded   DeviceChange = "added"
 	DeviceRemoved DeviceChange = "removed"
+
+	// DeviceUpdated is reported when an attached devices attributes change,
+	// only when enabled using WithDeviceUpdates
+	DeviceUpdated DeviceChange = "updated"
+
+	// DeviceSeen is a synthetic change replayed to new subscribers for each
+	// device the watcher currently knows to be attached
+	DeviceSeen DeviceChange = "seen"
 )
 
 // ChangedDevice represents the device that has changed
@@ -17,70 +29,450 @@ type ChangedDevice struct {
 	Change DeviceChange
 }
 
-// DeviceListener is a callback for when a device is added or removed
+// DeviceListener is a callback for when a device is added or removed. The
+// changes of each poll are reported in order of MAC address, and changes to
+// a single device are always reported in the order they were observed.
 type DeviceListener func(*ChangedDevice, error)
 
+// Watcher polls the router for attached devices, reporting changes to the
+// subscribed DeviceListeners
+type Watcher struct {
+	client   *Client
+	ticker   *time.Ticker
+	trigger  chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	// dispatchMu is held while changes are reported, so subscribers attach
+	// between polls and never miss or duplicate a change
+	dispatchMu   sync.Mutex
+	listeners    map[int]DeviceListener
+	nextListener int
+
+	detailedEvery int
+	updates       bool
+	signalAlpha   float64
+	beforePoll    func(poll int) bool
+	afterPoll     func(PollStats)
+	store         StateStore
+	identity      IdentityFunc
+	quietWindows  []QuietWindow
+	settle        time.Duration
+	guest         *guestShutoff
+	presence      *Presence
+
+	// gated is set while cycles are skipped by beforePoll, so the state saved
+	// by another instance is reloaded once polling resumes
+	gated bool
+
+	mu       sync.Mutex
+	polls    int
+	devices  []AttachedDevice
+	index    deviceIndex
+	detailed map[string]AttachedDevice
+	signals  map[string]float64
+	meta     map[string]DeviceMeta
+	churn    []churnEvent
+
+	quieted     bool
+	settleUntil time.Time
+}
+
+// WatchOption configures a Watcher
+type WatchOption func(*Watcher)
+
+// WithDetailedEvery makes every nth poll use DetailedDevices, with the
+// detailed fields carried over onto devices returned from the cheaper polls
+// in between. This balances the freshness of the detailed fields against the
+// load on slower routers. Using 1 will request detailed devices every poll.
+func WithDetailedEvery(n int) WatchOption {
+	return func(w *Watcher) {
+		w.detailedEvery = n
+	}
+}
+
+// WithDeviceUpdates reports a DeviceUpdated change when the IP address, name,
+// or connection of an attached device changes, or its MAC address when
+// devices are tracked by another identity (see WithIdentity). Signal strength and link rate
+// are not considered since they change nearly every poll.
+func WithDeviceUpdates() WatchOption {
+	return func(w *Watcher) {
+		w.updates = true
+	}
+}
+
+// WithKnownDevices seeds the watcher with a previously known list of attached
+// devices, so only changes since then are reported. Otherwise every attached
+// device is reported as added on the first poll.
+func WithKnownDevices(devices []AttachedDevice) WatchOption {
+	return func(w *Watcher) {
+		w.devices = devices
+		w.index = nil
+	}
+}
+
+// Watch starts polling the router for attached devices, triggering the
+// listener when a device is added or removed
+func (c *Client) Watch(poll time.Duration, fn DeviceListener, opts ...WatchOption) *Watcher {
+	w := &Watcher{
+		client:    c,
+		ticker:    time.NewTicker(poll),
+		trigger:   make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		listeners: map[int]DeviceListener{0: fn},
+		devices:   []AttachedDevice{},
+		detailed:  map[string]AttachedDevice{},
+		signals:   map[string]float64{},
+		meta:      map[string]DeviceMeta{},
+
+		nextListener: 1,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	c.trackWatcher(w)
+
+	go w.watch()
+
+	return w
+}
+
 // OnDeviceChanged triggers a callback when a device is added or removed
 func (c *Client) OnDeviceChanged(poll time.Duration, fn DeviceListener) *time.Ticker {
-	ticker := time.NewTicker(poll)
-	devices := []AttachedDevice{}
+	return c.Watch(poll, fn).ticker
+}
 
-	getDevices := func() ([]AttachedDevice, error) {
-		if err := c.Login(); err != nil {
-			return nil, err
+// Stop stops the watcher from polling the router, waiting for a poll in
+// progress to finish. Stop must not be called from within a listener.
+func (w *Watcher) Stop() {
+	w.halt()
+	w.wait()
+}
+
+func (w *Watcher) halt() {
+	w.stopOnce.Do(func() {
+		w.ticker.Stop()
+		close(w.done)
+		w.client.untrackWatcher(w)
+	})
+}
+
+func (w *Watcher) wait() {
+	<-w.stopped
+}
+
+// stopping reports if the watcher has been asked to stop
+func (w *Watcher) stopping() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// PollNow requests an immediate poll of the router outside of the regular
+// interval, for example when an external signal such as a router syslog line
+// or motion sensor suggests a device has arrived. Requests made while a poll
+// is already pending are coalesced. PollNow does not wait for the poll.
+func (w *Watcher) PollNow() {
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Subscribe attaches an additional listener to the watcher. When replay is
+// true the listener is immediately called with a DeviceSeen change for each
+// currently attached device, so it converges to the full device set without
+// racing the poll loop. The returned function detaches the listener.
+//
+// Subscribe must not be called from within a listener.
+func (w *Watcher) Subscribe(fn DeviceListener, replay bool) func() {
+	w.dispatchMu.Lock()
+	defer w.dispatchMu.Unlock()
+
+	if replay {
+		w.mu.Lock()
+		devices := w.devices
+		w.mu.Unlock()
+
+		for _, dev := range devices {
+			fn(&ChangedDevice{dev, DeviceSeen}, nil)
 		}
+	}
+
+	id := w.nextListener
+	w.listeners[id] = fn
+	w.nextListener++
 
-		return c.Devices()
+	return func() {
+		w.dispatchMu.Lock()
+		defer w.dispatchMu.Unlock()
+
+		delete(w.listeners, id)
+	}
+}
+
+// dispatch reports a change or error to all listeners. Must be called with
+// the dispatch lock held.
+func (w *Watcher) dispatch(change *ChangedDevice, err error) {
+	for _, fn := range w.listeners {
+		fn(change, err)
 	}
+}
 
-	watcher := func() {
-		for _ = range ticker.C {
-			updatedDevices, err := getDevices()
-			if err != nil {
-				fn(nil, err)
-				continue
-			}
+func (w *Watcher) pollDetailed() bool {
+	return w.detailedEvery > 0 && w.polls%w.detailedEvery == 0
+}
 
-			changedDevices := getChangedDevices(devices, updatedDevices)
-			for _, changedDevice := range changedDevices {
-				fn(&changedDevice, nil)
-			}
+func (w *Watcher) getDevices(detailed bool) ([]AttachedDevice, error) {
+	if err := w.client.Login(); err != nil {
+		return nil, err
+	}
 
-			devices = updatedDevices
+	if detailed {
+		return w.client.DetailedDevices()
+	}
+
+	return w.client.Devices()
+}
+
+// Carry over the detailed fields from the last detailed poll onto devices
+// returned from a cheaper poll. Must be called with the lock held.
+func (w *Watcher) mergeDetails(devices []AttachedDevice, detailed bool) {
+	if detailed {
+		w.detailed = make(map[string]AttachedDevice, len(devices))
+		for _, dev := range devices {
+			w.detailed[dev.MAC.String()] = dev
 		}
+		return
 	}
 
-	go watcher()
+	for i := range devices {
+		if dev, ok := w.detailed[devices[i].MAC.String()]; ok {
+			devices[i].mergeDetails(dev)
+		}
+	}
+}
+
+func (w *Watcher) watch() {
+	defer close(w.stopped)
 
-	return ticker
+	if w.store != nil {
+		w.loadState()
+	}
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.ticker.C:
+			w.poll()
+		case <-w.trigger:
+			w.poll()
+		}
+	}
 }
 
-// Determine what devices were changed between two lists of attached devices
-func getChangedDevices(oldDevices, newDevices []AttachedDevice) []ChangedDevice {
-	change := []ChangedDevice{}
-	diff := map[string]bool{}
+func (w *Watcher) poll() {
+	w.mu.Lock()
+	stats := PollStats{Poll: w.polls, Detailed: w.pollDetailed(), Started: time.Now()}
+	quiet := w.quiet(stats.Started)
+	w.mu.Unlock()
+
+	if quiet {
+		return
+	}
+
+	if w.beforePoll != nil && !w.beforePoll(stats.Poll) {
+		w.gated = true
+		return
+	}
+
+	if w.gated && w.store != nil {
+		w.loadState()
+
+		// The restored state may have been saved after more polls
+		w.mu.Lock()
+		stats.Poll = w.polls
+		stats.Detailed = w.pollDetailed()
+		w.mu.Unlock()
+	}
+	w.gated = false
+
+	if w.afterPoll != nil {
+		defer func() {
+			stats.Duration = time.Since(stats.Started)
+			w.afterPoll(stats)
+		}()
+	}
+
+	detailed := stats.Detailed
+
+	updatedDevices, err := w.getDevices(detailed)
+	if err != nil {
+		stats.Err = err
+
+		w.dispatchMu.Lock()
+		defer w.dispatchMu.Unlock()
+
+		// Calls cancelled by closing the client are not reported
+		if !w.stopping() {
+			w.dispatch(nil, err)
+		}
+		return
+	}
+
+	updatedDevices = w.update(updatedDevices, detailed, &stats)
+
+	// Guest networks are checked once the changes have been reported, since
+	// checking them calls the router and must not hold up subscribers
+	if w.guest != nil {
+		w.guest.observe(w.client, updatedDevices, detailed, time.Now())
+	}
+}
+
+// update applies the devices of a successful poll to the watcher state and
+// reports the changes, returning the devices as tracked by the watcher
+func (w *Watcher) update(updatedDevices []AttachedDevice, detailed bool, stats *PollStats) []AttachedDevice {
+	w.dispatchMu.Lock()
+	defer w.dispatchMu.Unlock()
+
+	w.mu.Lock()
+	w.mergeDetails(updatedDevices, detailed)
+	w.smoothSignals(updatedDevices)
+
+	// Rescore once the detailed fields and smoothed signals are known
+	w.client.scoreDevices(updatedDevices)
+	w.applyMeta(updatedDevices)
+	updatedDevices = w.retainMissing(updatedDevices, time.Now())
+	changedDevices, index := w.diff(updatedDevices)
+	sortChanges(changedDevices)
+	w.devices = updatedDevices
+	w.index = index
+
+	// The initial poll reports every attached device as added, these are
+	// not considered churn.
+	if w.polls > 0 {
+		w.recordChurn(changedDevices, time.Now())
+	}
+	w.polls++
+
+	var state *WatcherState
+	if w.store != nil {
+		state = w.state()
+	}
+	w.mu.Unlock()
+
+	if state != nil {
+		if err := w.store.Save(state); err != nil {
+			w.dispatch(nil, err)
+		}
+	}
+
+	stats.Devices = len(updatedDevices)
+	stats.Changes = len(changedDevices)
+
+	for _, changedDevice := range changedDevices {
+		w.dispatch(&changedDevice, nil)
+	}
+
+	return updatedDevices
+}
+
+// DevicesDelta gets the list of devices attached to the router along with the
+// changes since a previously retrieved list. This is useful for callers which
+// keep the previous list externally between polls.
+func (c *Client) DevicesDelta(previous []AttachedDevice) ([]AttachedDevice, []ChangedDevice, error) {
+	devices, err := c.Devices()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes, _ := diffDevices(previous, indexDevices(previous, IdentityMAC), devices, IdentityMAC, false)
+	sortChanges(changes)
+
+	return devices, changes, nil
+}
+
+// deviceIndex maps the identity of each device to its position in the list
+// of attached devices
+type deviceIndex map[string]int
 
-	for _, dev := range oldDevices {
-		diff[dev.MAC.String()] = true
+// indexDevices indexes devices by the identity determined by key. The first
+// of any devices sharing an identity is indexed.
+func indexDevices(devices []AttachedDevice, key func(AttachedDevice) string) deviceIndex {
+	index := make(deviceIndex, len(devices))
+	for i, dev := range devices {
+		k := key(dev)
+		if _, ok := index[k]; !ok {
+			index[k] = i
+		}
+	}
+
+	return index
+}
+
+// diff determines the changes between the known devices and an updated list
+// of devices, returning them along with the index of the updated list. The
+// index of the known devices is kept across polls, so each poll only indexes
+// the updated list once. Must be called with the lock held.
+func (w *Watcher) diff(devices []AttachedDevice) ([]ChangedDevice, deviceIndex) {
+	// The index is dropped whenever the known devices are replaced outside of
+	// a poll, such as when seeded or restored
+	if w.index == nil {
+		w.index = indexDevices(w.devices, w.key)
 	}
 
-	// Find newly added devices
-	for _, dev := range newDevices {
-		if _, ok := diff[dev.MAC.String()]; !ok {
+	return diffDevices(w.devices, w.index, devices, w.key, w.updates)
+}
+
+// diffDevices determines the changes between the known devices, indexed by
+// known, and an updated list of devices in a single pass over each list. The
+// index of the updated list is returned along with the changes. Updated
+// changes are only reported when updates is set.
+func diffDevices(previous []AttachedDevice, known deviceIndex, devices []AttachedDevice, key func(AttachedDevice) string, updates bool) ([]ChangedDevice, deviceIndex) {
+	change := []ChangedDevice{}
+	index := make(deviceIndex, len(devices))
+
+	for i, dev := range devices {
+		k := key(dev)
+		if _, ok := index[k]; ok {
+			continue
+		}
+		index[k] = i
+
+		j, ok := known[k]
+		if !ok {
 			change = append(change, ChangedDevice{dev, DeviceAdded})
 			continue
 		}
 
-		// Device remains unchanged
-		delete(diff, dev.MAC.String())
+		if updates && !sameAttributes(previous[j], dev) {
+			change = append(change, ChangedDevice{dev, DeviceUpdated})
+		}
 	}
 
-	// Find removed devices
-	for _, dev := range oldDevices {
-		if _, ok := diff[dev.MAC.String()]; ok {
-			change = append(change, ChangedDevice{dev, DeviceRemoved})
+	for k, j := range known {
+		if _, ok := index[k]; !ok {
+			change = append(change, ChangedDevice{previous[j], DeviceRemoved})
 		}
 	}
 
-	return change
+	return change, index
+}
+
+func sameAttributes(a, b AttachedDevice) bool {
+	return bytes.Equal(a.MAC, b.MAC) &&
+		a.IP.Equal(b.IP) &&
+		a.Name == b.Name &&
+		a.Type == b.Type &&
+		a.ConnectionType == b.ConnectionType &&
+		a.SSID == b.SSID &&
+		a.AccessPoint.String() == b.AccessPoint.String()
 }
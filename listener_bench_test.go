@@ -0,0 +1,40 @@
+package netgear
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+// benchDevices constructs n attached devices with sequential MAC addresses,
+// starting from offset
+func benchDevices(n, offset int) []AttachedDevice {
+	devices := make([]AttachedDevice, n)
+	for i := range devices {
+		id := offset + i
+		devices[i] = AttachedDevice{
+			MAC:  net.HardwareAddr{0xaa, 0xbb, 0xcc, byte(id >> 16), byte(id >> 8), byte(id)},
+			IP:   net.IPv4(10, byte(id>>16), byte(id>>8), byte(id)),
+			Name: fmt.Sprintf("device-%d", id),
+		}
+	}
+
+	return devices
+}
+
+func BenchmarkWatcherDiff(b *testing.B) {
+	for _, n := range []int{500, 1000, 2000} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			// A poll where a handful of devices left and others attached
+			w := &Watcher{updates: true, devices: benchDevices(n, 0)}
+			devices := benchDevices(n, 10)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				w.diff(devices)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package netgear_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+const waitTimeout = 2 * time.Second
+
+// watch starts a watcher that only polls when requested using PollNow, so
+// tests control exactly when each poll happens
+func watch(t *testing.T, client *netgear.Client, opts ...netgear.WatchOption) (*netgear.Watcher, *netgeartest.Recorder) {
+	t.Helper()
+
+	recorder := netgeartest.NewRecorder(16)
+	watcher := client.Watch(time.Hour, recorder.Listener(), opts...)
+	t.Cleanup(watcher.Stop)
+
+	return watcher, recorder
+}
+
+func expectChange(t *testing.T, r *netgeartest.Recorder, change netgear.DeviceChange, mac string) {
+	t.Helper()
+
+	if err := r.ExpectChange(waitTimeout, change, mac); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectError(t *testing.T, r *netgeartest.Recorder) error {
+	t.Helper()
+
+	ok, err := r.NextError(waitTimeout)
+	if !ok {
+		t.Fatal("Expected an error to be reported")
+	}
+
+	return err
+}
+
+func expectQuiet(t *testing.T, r *netgeartest.Recorder) {
+	t.Helper()
+
+	if err := r.ExpectQuiet(100 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchReportsChanges(t *testing.T) {
+	server := netgeartest.Start(t)
+	phone := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone")
+	laptop := netgeartest.Device(t, "aa:bb:cc:00:00:02", "192.168.1.3", "laptop")
+	server.SetDevices(laptop, phone)
+
+	watcher, recorder := watch(t, server.Client())
+
+	// Every attached device is reported as added on the first poll, in
+	// order of MAC address
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:01")
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:02")
+
+	watcher.PollNow()
+	expectQuiet(t, recorder)
+
+	tablet := netgeartest.Device(t, "aa:bb:cc:00:00:03", "192.168.1.4", "tablet")
+	server.SetDevices(tablet, laptop)
+
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceRemoved, "aa:bb:cc:00:00:01")
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:03")
+}
+
+func TestWatchDeviceUpdates(t *testing.T) {
+	server := netgeartest.Start(t)
+	phone := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone")
+	server.SetDevices(phone)
+
+	watcher, recorder := watch(t, server.Client(), netgear.WithDeviceUpdates())
+
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:01")
+
+	// Signal changes alone are not considered an update
+	phone.Signal = 20
+	server.SetDevices(phone)
+
+	watcher.PollNow()
+	expectQuiet(t, recorder)
+
+	phone.IP = phone.IP.To4()
+	phone.IP[3] = 20
+	server.SetDevices(phone)
+
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceUpdated, "aa:bb:cc:00:00:01")
+}
+
+func TestWatchDeviceClosed(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	client := server.Client()
+	mac := netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")
+
+	changes := client.WatchDevice(context.Background(), mac, time.Hour)
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing the client closes the channels of devices being watched, and
+	// watching once closed returns an already closed channel
+	for _, changes := range []<-chan netgear.ChangedDevice{changes, client.WatchDevice(context.Background(), mac, time.Hour)} {
+		timeout := time.After(waitTimeout)
+		for open := true; open; {
+			select {
+			case _, open = <-changes:
+			case <-timeout:
+				t.Fatal("Expected the channel to be closed")
+			}
+		}
+	}
+}
+
+func TestChurnStatsIgnoresUpdates(t *testing.T) {
+	server := netgeartest.Start(t)
+	phone := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone")
+	laptop := netgeartest.Device(t, "aa:bb:cc:00:00:02", "192.168.1.3", "laptop")
+	server.SetDevices(phone, laptop)
+
+	watcher, recorder := watch(t, server.Client(), netgear.WithDeviceUpdates())
+
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:01")
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:02")
+
+	phone.Name = "phone-renamed"
+	server.SetDevices(phone)
+
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceUpdated, "aa:bb:cc:00:00:01")
+	expectChange(t, recorder, netgear.DeviceRemoved, "aa:bb:cc:00:00:02")
+
+	churn := watcher.ChurnStats(time.Hour)
+	if len(churn) != 1 || churn[0].MAC != "aa:bb:cc:00:00:02" || churn[0].Leaves != 1 {
+		t.Errorf("Expected only the laptop leaving as churn, got %+v", churn)
+	}
+}
+
+func TestWatchAuthFailure(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+	server.FailAuth(1)
+
+	watcher, recorder := watch(t, server.Client())
+
+	watcher.PollNow()
+	err := expectError(t, recorder)
+
+	respErr := &netgear.ResponseError{}
+	if !errors.As(err, &respErr) || respErr.Op != "login" || respErr.Code != netgeartest.CodeUnauthorized {
+		t.Fatalf("Expected a login failure, got %v", err)
+	}
+
+	// A failed poll does not report the devices as removed, the next
+	// successful poll picks up where it left off
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:01")
+}
+
+func TestWatchTruncatedResponse(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	watcher, recorder := watch(t, server.Client())
+
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:01")
+
+	// Truncate both the login and device list responses
+	server.TruncateResponses(2)
+
+	watcher.PollNow()
+	expectError(t, recorder)
+	expectQuiet(t, recorder)
+
+	server.TruncateResponses(0)
+
+	watcher.PollNow()
+	expectQuiet(t, recorder)
+}
+
+func TestWatchReboot(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	watcher, recorder := watch(t, server.Client())
+
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:01")
+
+	server.Reboot(200 * time.Millisecond)
+
+	watcher.PollNow()
+	expectError(t, recorder)
+
+	// Devices attached before the reboot are not reported again once the
+	// router is back, the watcher logs in again on the next poll
+	time.Sleep(250 * time.Millisecond)
+
+	watcher.PollNow()
+	expectQuiet(t, recorder)
+
+	server.SetDevices()
+
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceRemoved, "aa:bb:cc:00:00:01")
+}
+
+func TestWatchLatency(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+	server.SetLatency(100 * time.Millisecond)
+
+	watcher, recorder := watch(t, server.Client())
+
+	// Requests made while a poll is pending are coalesced
+	watcher.PollNow()
+	watcher.PollNow()
+	watcher.PollNow()
+
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:01")
+
+	if err := recorder.ExpectQuiet(time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchDetailedEvery(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	polls := make(chan netgear.PollStats, 4)
+
+	watcher, recorder := watch(t, server.Client(),
+		netgear.WithDetailedEvery(2),
+		netgear.WithDeviceUpdates(),
+		netgear.WithAfterPoll(func(stats netgear.PollStats) { polls <- stats }),
+	)
+
+	for _, detailed := range []bool{true, false, true} {
+		watcher.PollNow()
+
+		select {
+		case stats := <-polls:
+			if stats.Detailed != detailed {
+				t.Fatalf("Poll %d: expected detailed %t", stats.Poll, detailed)
+			}
+		case <-time.After(waitTimeout):
+			t.Fatal("Poll did not complete")
+		}
+	}
+
+	change, err := recorder.NextChange(waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change.Device.SSID != "home" {
+		t.Errorf("Expected detailed SSID, got %q", change.Device.SSID)
+	}
+
+	// The detailed fields are carried over onto the cheaper poll, so the
+	// device is not reported as updated
+	expectQuiet(t, recorder)
+}
+
+func TestCloseStopsWatcher(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+	server.SetLatency(200 * time.Millisecond)
+
+	client := server.Client()
+	polled := make(chan struct{}, 1)
+
+	watcher, recorder := watch(t, client, netgear.WithAfterPoll(func(netgear.PollStats) {
+		polled <- struct{}{}
+	}))
+
+	watcher.PollNow()
+	time.Sleep(50 * time.Millisecond)
+
+	client.Close()
+
+	// The poll in progress has finished by the time Close returns, and the
+	// cancelled call is not reported
+	select {
+	case <-polled:
+	default:
+		t.Fatal("Close returned while a poll was in progress")
+	}
+
+	expectQuiet(t, recorder)
+}
+
+func TestDevicesDelta(t *testing.T) {
+	server := netgeartest.Start(t)
+	phone := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone")
+	laptop := netgeartest.Device(t, "aa:bb:cc:00:00:02", "192.168.1.3", "laptop")
+	server.SetDevices(phone)
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	previous, changes, err := client.DevicesDelta(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || changes[0].Change != netgear.DeviceAdded {
+		t.Fatalf("Expected the phone to be added, got %+v", changes)
+	}
+
+	server.SetDevices(laptop)
+
+	_, changes, err = client.DevicesDelta(previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 2 || changes[0].Change != netgear.DeviceRemoved || changes[1].Change != netgear.DeviceAdded {
+		t.Errorf("Expected the phone removed and laptop added, got %+v", changes)
+	}
+}
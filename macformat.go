@@ -0,0 +1,88 @@
+package netgear
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// MACFormat controls how MAC addresses are rendered as strings. The zero
+// value uses DefaultMACFormat.
+type MACFormat int
+
+// MAC address formats
+const (
+	MACColon      MACFormat = iota + 1 // aa:bb:cc:dd:ee:ff
+	MACColonUpper                      // AA:BB:CC:DD:EE:FF
+	MACDash                            // aa-bb-cc-dd-ee-ff
+	MACBare                            // aabbccddeeff
+)
+
+// DefaultMACFormat is used by clients without a MACFormat configured and by
+// AttachedDevice.String
+var DefaultMACFormat = MACColon
+
+var macFormatNames = map[MACFormat]string{
+	MACColon:      "colon",
+	MACColonUpper: "colon-upper",
+	MACDash:       "dash",
+	MACBare:       "bare",
+}
+
+// ParseMACFormat parses the name of a MACFormat, as returned by String
+func ParseMACFormat(name string) (MACFormat, error) {
+	for format, formatName := range macFormatNames {
+		if formatName == name {
+			return format, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Unknown MAC format %q, expected colon, colon-upper, dash, or bare", name)
+}
+
+func (f MACFormat) String() string {
+	if name, ok := macFormatNames[f]; ok {
+		return name
+	}
+
+	return "default"
+}
+
+// Format renders a MAC address
+func (f MACFormat) Format(mac net.HardwareAddr) string {
+	if f == 0 {
+		f = DefaultMACFormat
+	}
+
+	str := mac.String()
+
+	switch f {
+	case MACColonUpper:
+		return strings.ToUpper(str)
+	case MACDash:
+		return strings.ReplaceAll(str, ":", "-")
+	case MACBare:
+		return strings.ReplaceAll(str, ":", "")
+	}
+
+	return str
+}
+
+// WithMACFormat sets the format used by FormatMAC
+func WithMACFormat(f MACFormat) ClientOption {
+	return func(c *Client) {
+		c.macFormat = f
+	}
+}
+
+// FormatMAC renders a MAC address using the clients MACFormat. The address
+// is hashed when the client is configured with Privacy.
+func (c *Client) FormatMAC(mac net.HardwareAddr) string {
+	return c.macFormat.Format(c.privacy.MAC(mac))
+}
+
+// String formats the device as its label and MAC address, using the
+// DefaultMACFormat
+func (d AttachedDevice) String() string {
+	return fmt.Sprintf("%s (%s)", d.Label, MACFormat(0).Format(d.MAC))
+}
@@ -0,0 +1,64 @@
+package netgear
+
+import "net"
+
+// DeviceMeta is user provided context about a device, such as "this is the
+// washing machine", carried along with the device in watcher changes
+type DeviceMeta struct {
+	Owner string   `json:"owner,omitempty" yaml:"owner"`
+	Tags  []string `json:"tags,omitempty" yaml:"tags"`
+	Notes string   `json:"notes,omitempty" yaml:"notes"`
+}
+
+// IsZero reports if no metadata is set
+func (m DeviceMeta) IsZero() bool {
+	return m.Owner == "" && len(m.Tags) == 0 && m.Notes == ""
+}
+
+// WithDeviceMeta seeds the watcher with metadata for devices, keyed by MAC
+// address. Metadata is persisted with the watcher state when using
+// WithStateStore, metadata restored from the store takes precedence.
+func WithDeviceMeta(meta map[string]DeviceMeta) WatchOption {
+	return func(w *Watcher) {
+		for mac, m := range meta {
+			if hw, err := net.ParseMAC(mac); err == nil {
+				w.meta[hw.String()] = m
+			}
+		}
+	}
+}
+
+// SetMeta attaches metadata to a device, included with the device in all
+// following changes. Zero metadata removes it.
+func (w *Watcher) SetMeta(mac net.HardwareAddr, meta DeviceMeta) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if meta.IsZero() {
+		delete(w.meta, mac.String())
+	} else {
+		w.meta[mac.String()] = meta
+	}
+
+	for i := range w.devices {
+		if w.devices[i].MAC.String() == mac.String() {
+			w.devices[i].Meta = meta
+		}
+	}
+}
+
+// Meta gets the metadata attached to a device
+func (w *Watcher) Meta(mac net.HardwareAddr) DeviceMeta {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.meta[mac.String()]
+}
+
+// applyMeta attaches metadata to the devices. Must be called with the lock
+// held.
+func (w *Watcher) applyMeta(devices []AttachedDevice) {
+	for i := range devices {
+		devices[i].Meta = w.meta[devices[i].MAC.String()]
+	}
+}
@@ -0,0 +1,110 @@
+package netgear
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// NameResolver determines a display label for a device. An empty string
+// indicates the resolver has no name for the device, and the next resolver in
+// the NameChain should be tried.
+type NameResolver func(AttachedDevice) string
+
+// NameChain resolves device labels by trying each resolver in turn
+type NameChain []NameResolver
+
+// Label resolves the label for a device. Devices no resolver could name are
+// labeled using the last octets of their MAC address, use Client.FormatLabel
+// to output labels when the client is configured with Privacy.
+func (c NameChain) Label(dev AttachedDevice) string {
+	for _, resolve := range c {
+		if name := resolve(dev); name != "" {
+			return name
+		}
+	}
+
+	return "Device " + macSuffix(dev.MAC)
+}
+
+// DefaultNameChain labels devices using the name reported by the router
+var DefaultNameChain = NameChain{RouterName}
+
+// Names the router reports when it does not know a devices name
+var unknownNames = map[string]bool{
+	"":          true,
+	"<unknown>": true,
+	"unknown":   true,
+	"--":        true,
+}
+
+// RouterName resolves the name reported by the router, unless the router
+// does not know the name of the device.
+func RouterName(dev AttachedDevice) string {
+	name := strings.TrimSpace(dev.Name)
+	if unknownNames[strings.ToLower(name)] {
+		return ""
+	}
+
+	return name
+}
+
+// MappedNames resolves names from a user supplied mapping of MAC addresses to
+// names. MAC addresses may be in any format accepted by net.ParseMAC.
+func MappedNames(names map[string]string) NameResolver {
+	normalized := make(map[string]string, len(names))
+	for mac, name := range names {
+		if hwAddr, err := net.ParseMAC(mac); err == nil {
+			normalized[hwAddr.String()] = name
+		}
+	}
+
+	return func(dev AttachedDevice) string {
+		return normalized[dev.MAC.String()]
+	}
+}
+
+// VendorNames resolves a name from the vendor owning the devices OUI, along
+// with the last octets of the MAC address to tell devices apart. The lookup
+// function should return an empty string for unknown OUIs.
+func VendorNames(lookup func(oui net.HardwareAddr) string) NameResolver {
+	return func(dev AttachedDevice) string {
+		if len(dev.MAC) < 3 {
+			return ""
+		}
+
+		vendor := lookup(dev.MAC[:3])
+		if vendor == "" {
+			return ""
+		}
+
+		return fmt.Sprintf("%s %s", vendor, macSuffix(dev.MAC))
+	}
+}
+
+// macSuffix formats the last two octets of a MAC address
+func macSuffix(mac net.HardwareAddr) string {
+	if len(mac) < 2 {
+		return mac.String()
+	}
+
+	return mac[len(mac)-2:].String()
+}
+
+// WithNameChain sets the chain used to label devices returned by the client
+func WithNameChain(chain NameChain) ClientOption {
+	return func(c *Client) {
+		c.names = chain
+	}
+}
+
+func (c *Client) labelDevices(devices []AttachedDevice) {
+	chain := c.names
+	if chain == nil {
+		chain = DefaultNameChain
+	}
+
+	for i := range devices {
+		devices[i].Label = chain.Label(devices[i])
+	}
+}
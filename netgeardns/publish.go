@@ -0,0 +1,203 @@
+// Package netgeardns publishes the devices attached to a netgear router as
+// DNS records, so devices may be resolved by name using live router data.
+//
+//	publisher := &netgeardns.RFC2136{Server: "127.0.0.1:53", Zone: "lan."}
+//	syncer := netgeardns.NewSyncer(publisher, "lan.")
+//	watcher.Subscribe(syncer.Listener(nil), true)
+package netgeardns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// Record is the DNS record published for an attached device
+type Record struct {
+	// Name is the fully qualified name of the device, such as
+	// "living-room-tv.lan."
+	Name string
+	IP   net.IP
+
+	// TXT describes the device, as key=value pairs
+	TXT []string
+}
+
+// Publisher maintains DNS records. Publish replaces any existing records
+// with the same name.
+type Publisher interface {
+	Publish(record Record) error
+	Unpublish(record Record) error
+}
+
+// Syncer keeps a Publisher in sync with the devices reported by a Watcher.
+// Devices are published under their hostname, a device sharing the name of
+// one already published is suffixed with the end of its MAC address, or the
+// whole address when that also collides.
+type Syncer struct {
+	publisher Publisher
+	zone      string
+
+	mu        sync.Mutex
+	published map[string]Record
+
+	// owners maps each published name to the MAC address of its device
+	owners map[string]string
+}
+
+// NewSyncer constructs a Syncer publishing device records within the zone
+func NewSyncer(publisher Publisher, zone string) *Syncer {
+	return &Syncer{
+		publisher: publisher,
+		zone:      strings.TrimSuffix(zone, ".") + ".",
+		published: map[string]Record{},
+		owners:    map[string]string{},
+	}
+}
+
+// Listener is the DeviceListener publishing device changes. It should be
+// subscribed with replay enabled so devices attached before the subscription
+// are published. Publishing errors are reported through onError, which may
+// be nil.
+func (s *Syncer) Listener(onError func(error)) netgear.DeviceListener {
+	return func(change *netgear.ChangedDevice, err error) {
+		if err != nil {
+			return
+		}
+
+		if err := s.apply(change); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+func (s *Syncer) apply(change *netgear.ChangedDevice) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mac := change.Device.MAC.String()
+	old, published := s.published[mac]
+
+	if change.Change == netgear.DeviceRemoved {
+		if !published {
+			return nil
+		}
+
+		delete(s.published, mac)
+		delete(s.owners, old.Name)
+		return s.publisher.Unpublish(old)
+	}
+
+	record, ok := s.record(change.Device, old.Name)
+	if !ok {
+		return nil
+	}
+
+	// The devices name may have changed, remove the record under the old
+	// name before publishing the new one.
+	if published && old.Name != record.Name {
+		if err := s.publisher.Unpublish(old); err != nil {
+			return err
+		}
+		delete(s.published, mac)
+		delete(s.owners, old.Name)
+	}
+
+	if err := s.publisher.Publish(record); err != nil {
+		return err
+	}
+
+	s.published[mac] = record
+	s.owners[record.Name] = mac
+
+	return nil
+}
+
+// record builds the record of a device, named with the first of its
+// candidate names not owned by another device. The current name is kept
+// while it remains a candidate, so a device does not move to its plain name
+// once the device it collided with leaves.
+func (s *Syncer) record(dev netgear.AttachedDevice, current string) (Record, bool) {
+	label := Hostname(dev.Label)
+	if label == "" || dev.IP == nil {
+		return Record{}, false
+	}
+
+	mac := dev.MAC.String()
+	hex := strings.ReplaceAll(mac, ":", "")
+
+	candidates := []string{label + "." + s.zone}
+	for _, suffix := range []string{hex[len(hex)-4:], hex} {
+		candidates = append(candidates, suffixedHostname(label, suffix)+"."+s.zone)
+	}
+
+	name := ""
+	for _, candidate := range candidates {
+		if candidate == current {
+			name = current
+			break
+		}
+
+		if owner, ok := s.owners[candidate]; name == "" && (!ok || owner == mac) {
+			name = candidate
+		}
+	}
+
+	if name == "" {
+		return Record{}, false
+	}
+
+	return Record{
+		Name: name,
+		IP:   dev.IP,
+		TXT: []string{
+			"mac=" + mac,
+			"type=" + dev.Type,
+		},
+	}, true
+}
+
+// suffixedHostname appends a suffix to a hostname, shortening the hostname
+// so the label remains within 63 characters
+func suffixedHostname(label, suffix string) string {
+	if max := 63 - len(suffix) - 1; len(label) > max {
+		label = strings.TrimSuffix(label[:max], "-")
+	}
+
+	return label + "-" + suffix
+}
+
+// Hostname converts a device name into a DNS label. Characters which are not
+// valid in a hostname are replaced with hyphens.
+func Hostname(name string) string {
+	b := &strings.Builder{}
+	hyphen := false
+
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+
+		if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+
+	label := strings.TrimSuffix(b.String(), "-")
+	if len(label) > 63 {
+		label = strings.TrimSuffix(label[:63], "-")
+	}
+
+	return label
+}
+
+// recordError wraps errors publishing a record with its name
+func recordError(op string, record Record, err error) error {
+	return fmt.Errorf("Unable to %s %s: %s", op, record.Name, err)
+}
@@ -0,0 +1,70 @@
+package netgeardns_test
+
+import (
+	"net"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeardns"
+)
+
+// memoryPublisher holds published records by name, replacing records with
+// the same name like RFC2136
+type memoryPublisher struct {
+	records map[string]netgeardns.Record
+}
+
+func (p *memoryPublisher) Publish(record netgeardns.Record) error {
+	p.records[record.Name] = record
+	return nil
+}
+
+func (p *memoryPublisher) Unpublish(record netgeardns.Record) error {
+	delete(p.records, record.Name)
+	return nil
+}
+
+func change(kind netgear.DeviceChange, mac, ip, label string) *netgear.ChangedDevice {
+	hwAddr, _ := net.ParseMAC(mac)
+
+	return &netgear.ChangedDevice{
+		Change: kind,
+		Device: netgear.AttachedDevice{MAC: hwAddr, IP: net.ParseIP(ip), Label: label},
+	}
+}
+
+func TestSyncerNameCollision(t *testing.T) {
+	publisher := &memoryPublisher{records: map[string]netgeardns.Record{}}
+	listener := netgeardns.NewSyncer(publisher, "lan").Listener(func(err error) { t.Error(err) })
+
+	listener(change(netgear.DeviceAdded, "aa:bb:cc:00:00:01", "192.168.1.2", "iPhone"), nil)
+	listener(change(netgear.DeviceAdded, "aa:bb:cc:00:ee:ff", "192.168.1.3", "iPhone"), nil)
+
+	first, ok := publisher.records["iphone.lan."]
+	if !ok || !first.IP.Equal(net.ParseIP("192.168.1.2")) {
+		t.Fatalf("Expected the first device under its plain name, got %v", publisher.records)
+	}
+
+	second, ok := publisher.records["iphone-eeff.lan."]
+	if !ok || !second.IP.Equal(net.ParseIP("192.168.1.3")) {
+		t.Fatalf("Expected the second device under a suffixed name, got %v", publisher.records)
+	}
+
+	// Removing the second device leaves the first devices record alone
+	listener(change(netgear.DeviceRemoved, "aa:bb:cc:00:ee:ff", "192.168.1.3", "iPhone"), nil)
+
+	if _, ok := publisher.records["iphone.lan."]; !ok {
+		t.Error("Expected the first devices record to remain")
+	}
+	if _, ok := publisher.records["iphone-eeff.lan."]; ok {
+		t.Error("Expected the second devices record to be removed")
+	}
+
+	// The name is only released once its owner leaves
+	listener(change(netgear.DeviceRemoved, "aa:bb:cc:00:00:01", "192.168.1.2", "iPhone"), nil)
+	listener(change(netgear.DeviceAdded, "aa:bb:cc:00:ee:ff", "192.168.1.3", "iPhone"), nil)
+
+	if record, ok := publisher.records["iphone.lan."]; !ok || !record.IP.Equal(net.ParseIP("192.168.1.3")) {
+		t.Errorf("Expected the released name to be reused, got %v", publisher.records)
+	}
+}
@@ -0,0 +1,115 @@
+package netgeardns
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// RFC2136 publishes records to a DNS server using dynamic updates
+type RFC2136 struct {
+	// Server is the address of the DNS server, as host:port
+	Server string
+
+	// Zone is the zone being updated
+	Zone string
+
+	// TTL of published records, defaults to 60 seconds
+	TTL time.Duration
+
+	// TSIG authentication of updates. Algorithm defaults to hmac-sha256.
+	TSIGName      string
+	TSIGSecret    string
+	TSIGAlgorithm string
+}
+
+// Publish implements Publisher
+func (p *RFC2136) Publish(record Record) error {
+	rrs, err := p.resourceRecords(record)
+	if err != nil {
+		return err
+	}
+
+	m := &dns.Msg{}
+	m.SetUpdate(dns.Fqdn(p.Zone))
+	m.RemoveName(rrs[:1])
+	m.Insert(rrs)
+
+	if err := p.exchange(m); err != nil {
+		return recordError("publish", record, err)
+	}
+
+	return nil
+}
+
+// Unpublish implements Publisher
+func (p *RFC2136) Unpublish(record Record) error {
+	rrs, err := p.resourceRecords(record)
+	if err != nil {
+		return err
+	}
+
+	m := &dns.Msg{}
+	m.SetUpdate(dns.Fqdn(p.Zone))
+	m.RemoveName(rrs[:1])
+
+	if err := p.exchange(m); err != nil {
+		return recordError("unpublish", record, err)
+	}
+
+	return nil
+}
+
+func (p *RFC2136) resourceRecords(record Record) ([]dns.RR, error) {
+	ttl := uint32(p.TTL.Seconds())
+	if p.TTL == 0 {
+		ttl = 60
+	}
+
+	header := func(rrType uint16) dns.RR_Header {
+		return dns.RR_Header{Name: dns.Fqdn(record.Name), Rrtype: rrType, Class: dns.ClassINET, Ttl: ttl}
+	}
+
+	var rrs []dns.RR
+
+	if ip4 := record.IP.To4(); ip4 != nil {
+		rrs = append(rrs, &dns.A{Hdr: header(dns.TypeA), A: ip4})
+	} else if record.IP != nil {
+		rrs = append(rrs, &dns.AAAA{Hdr: header(dns.TypeAAAA), AAAA: record.IP})
+	} else {
+		return nil, fmt.Errorf("Record %s has no address", record.Name)
+	}
+
+	if len(record.TXT) > 0 {
+		rrs = append(rrs, &dns.TXT{Hdr: header(dns.TypeTXT), Txt: record.TXT})
+	}
+
+	return rrs, nil
+}
+
+func (p *RFC2136) exchange(m *dns.Msg) error {
+	client := &dns.Client{}
+
+	if p.TSIGName != "" {
+		algorithm := p.TSIGAlgorithm
+		if algorithm == "" {
+			algorithm = dns.HmacSHA256
+		}
+
+		name := dns.Fqdn(p.TSIGName)
+		client.TsigSecret = map[string]string{name: p.TSIGSecret}
+		m.SetTsig(name, dns.Fqdn(algorithm), 300, time.Now().Unix())
+	}
+
+	resp, _, err := client.Exchange(m, p.Server)
+	if err != nil {
+		return err
+	}
+
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("Server responded with %s", dns.RcodeToString[resp.Rcode])
+	}
+
+	return nil
+}
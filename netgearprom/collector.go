@@ -0,0 +1,104 @@
+// Package netgearprom exposes netgear router metrics as a prometheus
+// collector.
+//
+//	client := netgear.NewClient("192.168.1.1", "admin", "password")
+//	prometheus.MustRegister(netgearprom.NewCollector(client))
+package netgearprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+const namespace = "netgear"
+
+// Collector implements prometheus.Collector, querying the router for
+// attached devices each time metrics are collected.
+type Collector struct {
+	client *netgear.Client
+
+	up         *prometheus.Desc
+	devices    *prometheus.Desc
+	deviceInfo *prometheus.Desc
+	signal     *prometheus.Desc
+	linkRate   *prometheus.Desc
+}
+
+// NewCollector constructs a Collector for the router the client talks to
+func NewCollector(client *netgear.Client) *Collector {
+	deviceLabels := []string{"mac"}
+
+	return &Collector{
+		client: client,
+		up: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "up"),
+			"Whether the router could be queried for attached devices.",
+			nil, nil,
+		),
+		devices: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "attached_devices"),
+			"Number of devices attached to the router.",
+			nil, nil,
+		),
+		deviceInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "device", "info"),
+			"Information about a device attached to the router.",
+			[]string{"mac", "ip", "name", "type"}, nil,
+		),
+		signal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "device", "signal"),
+			"Signal strength of a wireless device attached to the router.",
+			deviceLabels, nil,
+		),
+		linkRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "device", "link_rate"),
+			"Link rate of a device attached to the router.",
+			deviceLabels, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.up
+	ch <- c.devices
+	ch <- c.deviceInfo
+	ch <- c.signal
+	ch <- c.linkRate
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	devices, err := c.getDevices()
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(c.devices, prometheus.GaugeValue, float64(len(devices)))
+
+	for _, dev := range devices {
+		mac := c.client.FormatMAC(dev.MAC)
+
+		ch <- prometheus.MustNewConstMetric(
+			c.deviceInfo, prometheus.GaugeValue, 1,
+			mac, c.client.FormatIP(dev.IP), c.client.FormatName(dev.Name), dev.Type,
+		)
+
+		ch <- prometheus.MustNewConstMetric(c.linkRate, prometheus.GaugeValue, float64(dev.LinkRate), mac)
+
+		if dev.Type == "wireless" {
+			ch <- prometheus.MustNewConstMetric(c.signal, prometheus.GaugeValue, float64(dev.Signal), mac)
+		}
+	}
+}
+
+func (c *Collector) getDevices() ([]netgear.AttachedDevice, error) {
+	if err := c.client.Login(); err != nil {
+		return nil, err
+	}
+
+	return c.client.Devices()
+}
@@ -0,0 +1,114 @@
+package netgearprom
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// TrafficCollector implements prometheus.Collector, exporting the total
+// traffic through the router as counters. Totals are accumulated from a
+// TrafficWatcher, so they keep increasing across router reboots and traffic
+// meter resets.
+type TrafficCollector struct {
+	upload   *prometheus.Desc
+	download *prometheus.Desc
+	resets   *prometheus.Desc
+
+	mu            sync.Mutex
+	uploadTotal   float64
+	downloadTotal float64
+	resetTotal    float64
+}
+
+// NewTrafficCollector constructs a TrafficCollector. Attach Listener to a
+// TrafficWatcher.
+func NewTrafficCollector() *TrafficCollector {
+	return &TrafficCollector{
+		upload: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "traffic", "upload_megabytes_total"),
+			"Total megabytes uploaded through the router.",
+			nil, nil,
+		),
+		download: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "traffic", "download_megabytes_total"),
+			"Total megabytes downloaded through the router.",
+			nil, nil,
+		),
+		resets: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "traffic", "counter_resets_total"),
+			"Number of times the routers traffic counters were seen to reset.",
+			nil, nil,
+		),
+	}
+}
+
+// Listener is the TrafficListener accumulating the traffic totals
+func (c *TrafficCollector) Listener() netgear.TrafficListener {
+	return func(sample *netgear.TrafficSample, err error) {
+		if err != nil {
+			return
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.uploadTotal += sample.Upload
+		c.downloadTotal += sample.Download
+		if sample.Reset {
+			c.resetTotal++
+		}
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *TrafficCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.upload
+	ch <- c.download
+	ch <- c.resets
+}
+
+// Collect implements prometheus.Collector
+func (c *TrafficCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(c.upload, prometheus.CounterValue, c.uploadTotal)
+	ch <- prometheus.MustNewConstMetric(c.download, prometheus.CounterValue, c.downloadTotal)
+	ch <- prometheus.MustNewConstMetric(c.resets, prometheus.CounterValue, c.resetTotal)
+}
+
+// TrafficSnapshot is the accumulated totals of a TrafficCollector. Persisting
+// a snapshot on shutdown and restoring it at start keeps the counters
+// increasing across restarts, rather than resetting to zero. Traffic while
+// stopped is not counted.
+type TrafficSnapshot struct {
+	Upload   float64 `json:"upload"`
+	Download float64 `json:"download"`
+	Resets   float64 `json:"resets"`
+}
+
+// Snapshot captures the accumulated totals
+func (c *TrafficCollector) Snapshot() TrafficSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return TrafficSnapshot{
+		Upload:   c.uploadTotal,
+		Download: c.downloadTotal,
+		Resets:   c.resetTotal,
+	}
+}
+
+// Restore replaces the accumulated totals with those of a snapshot. Restore
+// before attaching the Listener, so no samples are lost.
+func (c *TrafficCollector) Restore(s TrafficSnapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.uploadTotal = s.Upload
+	c.downloadTotal = s.Download
+	c.resetTotal = s.Resets
+}
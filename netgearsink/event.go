@@ -0,0 +1,54 @@
+// Package netgearsink publishes device changes reported by a netgear router
+// to webhooks and MQTT brokers.
+//
+//	hook := &netgearsink.Webhook{Client: client, URL: "http://localhost:8080/hook"}
+//	watcher := client.Watch(10*time.Second, func(change *netgear.ChangedDevice, err error) {
+//		if err == nil {
+//			e := netgearsink.NewEvent(client, change)
+//			hook.Post(e.Change, e.MAC, e)
+//		}
+//	})
+package netgearsink
+
+import (
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// DefaultTimeout bounds webhook requests and MQTT operations when no timeout
+// is configured
+const DefaultTimeout = 10 * time.Second
+
+// Event is the published form of a device change. Commands embed it to add
+// fields of their own.
+type Event struct {
+	MAC        string    `json:"mac"`
+	Randomized bool      `json:"randomized"`
+	IP         string    `json:"ip,omitempty"`
+	Name       string    `json:"name"`
+	Change     string    `json:"change"`
+	Time       time.Time `json:"time"`
+
+	Meta *netgear.DeviceMeta `json:"meta,omitempty"`
+}
+
+// NewEvent constructs the event for a device change. Device identifiers and
+// metadata are rendered by the client, so they are redacted when it is
+// configured with Privacy.
+func NewEvent(client *netgear.Client, change *netgear.ChangedDevice) Event {
+	e := Event{
+		MAC:        client.FormatMAC(change.Device.MAC),
+		Randomized: change.Device.IsRandomized(),
+		IP:         client.FormatIP(change.Device.IP),
+		Name:       client.FormatLabel(change.Device),
+		Change:     string(change.Change),
+		Time:       time.Now(),
+	}
+
+	if meta := client.FormatMeta(change.Device.Meta); !meta.IsZero() {
+		e.Meta = &meta
+	}
+
+	return e
+}
@@ -0,0 +1,34 @@
+package netgearsink_test
+
+import (
+	"net"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgearsink"
+)
+
+func TestNewEventPrivacy(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:00:00:01")
+
+	change := &netgear.ChangedDevice{
+		Change: netgear.DeviceAdded,
+		Device: netgear.AttachedDevice{
+			MAC:   mac,
+			IP:    net.ParseIP("192.168.1.2"),
+			Label: "phone",
+			Meta:  netgear.DeviceMeta{Owner: "alice", Tags: []string{"family"}, Notes: "Work phone"},
+		},
+	}
+
+	plain := netgearsink.NewEvent(netgear.NewClient("router", "admin", "password"), change)
+	if plain.Meta == nil || plain.Meta.Owner != "alice" {
+		t.Errorf("Expected the metadata without privacy, got %+v", plain.Meta)
+	}
+
+	private := netgear.NewClient("router", "admin", "password", netgear.WithPrivacy(&netgear.Privacy{Key: []byte("key")}))
+
+	if e := netgearsink.NewEvent(private, change); e.Meta != nil || e.IP != "" || e.MAC == plain.MAC {
+		t.Errorf("Expected the event to be redacted, got %+v", e)
+	}
+}
@@ -0,0 +1,109 @@
+package netgearsink
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// DefaultTopicPrefix prefixes the MQTT topics when no prefix is configured
+const DefaultTopicPrefix = "netgear/presence"
+
+// MQTT publishes a retained presence state per device to
+// <prefix>/<mac>/state, along with each event to <prefix>/events
+type MQTT struct {
+	// Broker is the broker URL, such as tcp://localhost:1883
+	Broker string
+
+	// ClientID identifies the connection, brokers disconnect an existing
+	// client when another connects with the same ID. A unique ID prefixed
+	// with netgear- is generated when empty.
+	ClientID string
+
+	Username string
+	Password string
+
+	// TopicPrefix prefixes the published topics, DefaultTopicPrefix when
+	// empty
+	TopicPrefix string
+
+	// Timeout bounds connecting and each publish, DefaultTimeout when zero
+	Timeout time.Duration
+
+	client mqtt.Client
+}
+
+// Connect connects to the broker, reconnecting automatically once connected
+func (m *MQTT) Connect() error {
+	clientID := m.ClientID
+	if clientID == "" {
+		id := make([]byte, 4)
+		rand.Read(id)
+		clientID = "netgear-" + hex.EncodeToString(id)
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(m.Broker).
+		SetClientID(clientID).
+		SetUsername(m.Username).
+		SetPassword(m.Password).
+		SetAutoReconnect(true)
+
+	m.client = mqtt.NewClient(opts)
+
+	return m.wait(m.client.Connect())
+}
+
+// PublishState publishes the retained presence state of a device, home or
+// not_home
+func (m *MQTT) PublishState(mac string, home bool) error {
+	state := "home"
+	if !home {
+		state = "not_home"
+	}
+
+	return m.wait(m.client.Publish(m.prefix()+"/"+mac+"/state", 1, true, state))
+}
+
+// PublishEvent publishes an event as JSON
+func (m *MQTT) PublishEvent(e interface{}) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return m.wait(m.client.Publish(m.prefix()+"/events", 1, false, payload))
+}
+
+// Close disconnects from the broker, allowing in flight publishes to finish
+func (m *MQTT) Close() {
+	m.client.Disconnect(uint(m.timeout().Milliseconds()))
+}
+
+func (m *MQTT) prefix() string {
+	if m.TopicPrefix == "" {
+		return DefaultTopicPrefix
+	}
+
+	return m.TopicPrefix
+}
+
+func (m *MQTT) timeout() time.Duration {
+	if m.Timeout == 0 {
+		return DefaultTimeout
+	}
+
+	return m.Timeout
+}
+
+func (m *MQTT) wait(token mqtt.Token) error {
+	if !token.WaitTimeout(m.timeout()) {
+		return fmt.Errorf("Timed out after %s waiting for MQTT broker %s", m.timeout(), m.Broker)
+	}
+
+	return token.Error()
+}
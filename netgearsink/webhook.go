@@ -0,0 +1,94 @@
+package netgearsink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// Webhook formats
+const (
+	FormatJSON              = "json"
+	FormatCloudEvents       = "cloudevents"
+	FormatCloudEventsBinary = "cloudevents-binary"
+)
+
+// Webhook posts events to a URL, either as plain JSON or as a CloudEvent in
+// structured or binary mode
+type Webhook struct {
+	// Client is the router client the events were reported by, used as the
+	// source of CloudEvents
+	Client *netgear.Client
+
+	URL string
+
+	// Format is one of the webhook formats, FormatJSON when empty
+	Format string
+
+	// Timeout bounds each request, DefaultTimeout when zero
+	Timeout time.Duration
+}
+
+// ValidFormat checks a webhook format is known, the empty format is JSON
+func ValidFormat(format string) bool {
+	switch format {
+	case "", FormatJSON, FormatCloudEvents, FormatCloudEventsBinary:
+		return true
+	}
+
+	return false
+}
+
+// Post sends an event for a change to the URL. CloudEvents are typed as
+// device.<change>, with the subject typically being the devices MAC address.
+func (w *Webhook) Post(change, subject string, payload interface{}) error {
+	req, err := w.request(change, subject, payload)
+	if err != nil {
+		return err
+	}
+
+	timeout := w.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook %s responded with %s", w.URL, resp.Status)
+	}
+
+	return nil
+}
+
+func (w *Webhook) request(change, subject string, payload interface{}) (*http.Request, error) {
+	cloudEvent := w.Client.NewCloudEvent("device."+change, subject, payload)
+
+	switch w.Format {
+	case FormatCloudEvents:
+		return cloudEvent.StructuredRequest(w.URL)
+	case FormatCloudEventsBinary:
+		return cloudEvent.BinaryRequest(w.URL)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
@@ -0,0 +1,58 @@
+package netgearsink_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgearsink"
+)
+
+func TestWebhookPost(t *testing.T) {
+	received := make(chan netgearsink.Event, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := netgearsink.Event{}
+		json.NewDecoder(r.Body).Decode(&e)
+		received <- e
+	}))
+	defer server.Close()
+
+	hook := &netgearsink.Webhook{Client: netgear.NewClient("router", "admin", "password"), URL: server.URL}
+
+	if err := hook.Post("added", "aa:bb:cc:00:00:01", netgearsink.Event{MAC: "aa:bb:cc:00:00:01", Change: "added"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if e := <-received; e.MAC != "aa:bb:cc:00:00:01" || e.Change != "added" {
+		t.Errorf("Unexpected event %+v", e)
+	}
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	hook := &netgearsink.Webhook{
+		Client:  netgear.NewClient("router", "admin", "password"),
+		URL:     server.URL,
+		Timeout: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	if err := hook.Post("added", "", netgearsink.Event{}); err == nil {
+		t.Fatal("Expected the request to time out")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Request took %s, expected it to time out", elapsed)
+	}
+}
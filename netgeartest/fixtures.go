@@ -0,0 +1,156 @@
+package netgeartest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Fixture is a SOAP response recorded from a real router. Fixtures are stored
+// as JSON so they may be contributed to the corpus for firmware the parsers
+// have not been verified against.
+type Fixture struct {
+	Model    string `json:"model"`
+	Firmware string `json:"firmware"`
+	Action   string `json:"action"`
+	Payload  string `json:"payload"`
+}
+
+// Filename is the name the fixture is stored under in the corpus, formatted
+// as <model>_<firmware>_<service>-<method>.json. Method names are only unique
+// within a service.
+func (f Fixture) Filename() string {
+	clean := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r == '/' || r == ' ' || r == os.PathSeparator {
+				return '-'
+			}
+			return r
+		}, s)
+	}
+
+	action := strings.Replace(f.Action, "#", "-", 1)
+
+	return fmt.Sprintf("%s_%s_%s.json", clean(f.Model), clean(f.Firmware), clean(action))
+}
+
+// LoadFixtures reads every fixture in a corpus directory
+func LoadFixtures(dir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := make([]Fixture, 0, len(paths))
+
+	for _, path := range paths {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		fixture := Fixture{}
+		if err := json.Unmarshal(contents, &fixture); err != nil {
+			return nil, fmt.Errorf("Unable to load fixture %s: %s", path, err)
+		}
+
+		if fixture.Action == "" || fixture.Payload == "" {
+			return nil, fmt.Errorf("Fixture %s is missing an action or payload", path)
+		}
+
+		fixtures = append(fixtures, fixture)
+	}
+
+	return fixtures, nil
+}
+
+// WriteFixture sanitizes the fixture and writes it into the corpus directory
+func WriteFixture(dir string, f Fixture) (string, error) {
+	f.Payload = Sanitize(f.Payload)
+
+	contents, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, f.Filename())
+
+	return path, os.WriteFile(path, append(contents, '\n'), 0o644)
+}
+
+var (
+	macPattern       = regexp.MustCompile(`(?i)\b[0-9a-f]{2}(:[0-9a-f]{2}){5}\b`)
+	devicesPattern   = regexp.MustCompile(`(?s)<NewAttachDevice>([^<]*)</NewAttachDevice>`)
+	sensitivePattern = regexp.MustCompile(`(?s)<(Name|SSID|NewSSID|NewKey|SerialNumber|DeviceName)>[^<]*</(Name|SSID|NewSSID|NewKey|SerialNumber|DeviceName)>`)
+	wanPattern       = regexp.MustCompile(`<(NewExternalIPAddress|NewDefaultGateway)>[^<]*</(NewExternalIPAddress|NewDefaultGateway)>`)
+)
+
+// wanAddresses replace the WAN addresses in recorded payloads, taken from the
+// documentation address range
+var wanAddresses = map[string]string{
+	"NewExternalIPAddress": "203.0.113.10",
+	"NewDefaultGateway":    "203.0.113.1",
+}
+
+// Sanitize scrubs identifying details from a recorded payload. MAC addresses
+// are consistently replaced with locally administered addresses, so devices
+// remain distinguishable, WAN addresses are replaced with documentation
+// addresses, while device names, SSIDs, keys, and serial numbers are replaced
+// outright.
+func Sanitize(payload string) string {
+	macs := map[string]string{}
+
+	payload = macPattern.ReplaceAllStringFunc(payload, func(mac string) string {
+		mac = strings.ToUpper(mac)
+
+		fake, ok := macs[mac]
+		if !ok {
+			n := len(macs) + 1
+			fake = fmt.Sprintf("02:00:00:00:%02X:%02X", n>>8, n&0xff)
+			macs[mac] = fake
+		}
+
+		return fake
+	})
+
+	// Device names in the GetAttachDevice list are the third field of each
+	// ';' separated device entry.
+	payload = devicesPattern.ReplaceAllStringFunc(payload, func(match string) string {
+		list := devicesPattern.FindStringSubmatch(match)[1]
+		devStrs := strings.Split(list, "@")
+
+		for i := 1; i < len(devStrs); i++ {
+			parts := strings.Split(devStrs[i], ";")
+			if len(parts) > 2 {
+				parts[2] = fmt.Sprintf("device-%d", i)
+			}
+			devStrs[i] = strings.Join(parts, ";")
+		}
+
+		return "<NewAttachDevice>" + strings.Join(devStrs, "@") + "</NewAttachDevice>"
+	})
+
+	payload = sensitivePattern.ReplaceAllStringFunc(payload, func(match string) string {
+		element := sensitivePattern.FindStringSubmatch(match)[1]
+		return fmt.Sprintf("<%[1]s>redacted</%[1]s>", element)
+	})
+
+	payload = wanPattern.ReplaceAllStringFunc(payload, func(match string) string {
+		element := wanPattern.FindStringSubmatch(match)[1]
+		return fmt.Sprintf("<%[1]s>%[2]s</%[1]s>", element, wanAddresses[element])
+	})
+
+	return payload
+}
+
+// ServeFixture makes the mock router respond to the fixtures action with the
+// recorded payload, in place of its simulated response.
+func (s *Server) ServeFixture(f Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fixtures[f.Action] = f.Payload
+}
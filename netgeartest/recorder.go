@@ -0,0 +1,86 @@
+package netgeartest
+
+import (
+	"fmt"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// Recorder collects the changes and errors reported to a DeviceListener so
+// they may be inspected in order.
+type Recorder struct {
+	changes chan netgear.ChangedDevice
+	errors  chan error
+}
+
+// NewRecorder constructs a Recorder buffering up to size changes and errors
+func NewRecorder(size int) *Recorder {
+	return &Recorder{
+		changes: make(chan netgear.ChangedDevice, size),
+		errors:  make(chan error, size),
+	}
+}
+
+// Listener returns the DeviceListener feeding this recorder
+func (r *Recorder) Listener() netgear.DeviceListener {
+	return func(change *netgear.ChangedDevice, err error) {
+		if err != nil {
+			r.errors <- err
+			return
+		}
+
+		r.changes <- *change
+	}
+}
+
+// NextChange waits for the next reported device change
+func (r *Recorder) NextChange(timeout time.Duration) (netgear.ChangedDevice, error) {
+	select {
+	case change := <-r.changes:
+		return change, nil
+	case <-time.After(timeout):
+		return netgear.ChangedDevice{}, fmt.Errorf("No device change within %s", timeout)
+	}
+}
+
+// NextError waits for the next reported error. False is returned if no error
+// is reported within the timeout.
+func (r *Recorder) NextError(timeout time.Duration) (bool, error) {
+	select {
+	case err := <-r.errors:
+		return true, err
+	case <-time.After(timeout):
+		return false, nil
+	}
+}
+
+// ExpectChange waits for the next device change and verifies it matches the
+// expected change for the given MAC address.
+func (r *Recorder) ExpectChange(timeout time.Duration, change netgear.DeviceChange, mac string) error {
+	got, err := r.NextChange(timeout)
+	if err != nil {
+		return err
+	}
+
+	if got.Change != change || got.Device.MAC.String() != mac {
+		return fmt.Errorf(
+			"Expected %s %s, got %s %s",
+			change, mac, got.Change, got.Device.MAC,
+		)
+	}
+
+	return nil
+}
+
+// ExpectQuiet verifies no changes or errors are reported within the duration
+func (r *Recorder) ExpectQuiet(d time.Duration) error {
+	select {
+	case change := <-r.changes:
+		return fmt.Errorf("Unexpected change %s %s", change.Change, change.Device.MAC)
+	case err := <-r.errors:
+		return fmt.Errorf("Unexpected error %s", err)
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,716 @@
+// Package netgeartest provides a mock netgear router SOAP server, with fault
+// injection, for exercising the netgear client and device watcher.
+package netgeartest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+const soapResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<soap-env:Envelope
+  xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"
+  soap-env:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<soap-env:Body>
+<m:%[1]sResponse xmlns:m="%[2]s">
+%[3]s</m:%[1]sResponse>
+<ResponseCode>%03[4]d</ResponseCode>
+</soap-env:Body>
+</soap-env:Envelope>`
+
+// Version 2 of a service reports the response code within the action
+// response element
+const soapResponseV2 = `<?xml version="1.0" encoding="UTF-8"?>
+<soap-env:Envelope
+  xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"
+  soap-env:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+<soap-env:Body>
+<m:%[1]sResponse xmlns:m="%[2]s">
+%[3]s<ResponseCode>%03[4]d</ResponseCode>
+</m:%[1]sResponse>
+</soap-env:Body>
+</soap-env:Envelope>`
+
+// Response codes returned by the mock router
+const (
+	CodeOK           = 0
+	CodeUnauthorized = 401
+	CodeNotSupported = 501
+)
+
+// Server is a mock netgear router. The zero value is not usable, construct
+// one using NewServer.
+type Server struct {
+	*httptest.Server
+
+	Username string
+	Password string
+
+	mu            sync.Mutex
+	versions      map[string]int
+	fixtures      map[string]string
+	info          netgear.RouterInfo
+	devices       []netgear.AttachedDevice
+	blocked       map[string]bool
+	accessControl bool
+	guest         map[netgear.Band]netgear.GuestNetwork
+	meterOptions  *netgear.TrafficMeterOptions
+	meter         netgear.TrafficMeter
+	configuring   bool
+	authenticated bool
+	authFailures  int
+	truncations   int
+	latency       time.Duration
+	clockOffset   time.Duration
+	rebootedUntil time.Time
+}
+
+// NewServer starts a mock router accepting the given credentials. The server
+// should be closed once it is no longer needed.
+func NewServer(username, password string) *Server {
+	s := &Server{
+		Username: username,
+		Password: password,
+		versions: map[string]int{},
+		fixtures: map[string]string{},
+		blocked:  map[string]bool{},
+		guest:    map[netgear.Band]netgear.GuestNetwork{},
+
+		info: netgear.RouterInfo{
+			Model:    "R7000",
+			Firmware: "V1.0.11.116_10.2.100",
+		},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// Client constructs a netgear.Client configured to talk to the mock router
+// using the servers credentials, along with any further options.
+func (s *Server) Client(opts ...netgear.ClientOption) *netgear.Client {
+	addr := s.Listener.Addr().(*net.TCPAddr)
+
+	client := netgear.NewClient(addr.IP.String(), s.Username, s.Password, opts...)
+	client.Port = addr.Port
+
+	return client
+}
+
+// SetServiceVersion sets the newest version of a service the mock router
+// implements. Requests for newer versions are rejected as not supported.
+// Services default to only implementing version 1.
+func (s *Server) SetServiceVersion(service string, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.versions[service] = version
+}
+
+// SetInfo sets the model and firmware information reported by the mock router
+func (s *Server) SetInfo(info netgear.RouterInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.info = info
+}
+
+// SetDevices replaces the list of devices attached to the mock router
+func (s *Server) SetDevices(devices ...netgear.AttachedDevice) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.devices = devices
+}
+
+// Blocked reports if the device has been blocked from accessing the internet
+// through access control
+func (s *Server) Blocked(mac net.HardwareAddr) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accessControl && s.blocked[strings.ToUpper(mac.String())]
+}
+
+// SetAccessControl enables or disables access control on the mock router,
+// which otherwise ignores blocked devices
+func (s *Server) SetAccessControl(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accessControl = enabled
+}
+
+// AccessControl reports if access control is enabled on the mock router
+func (s *Server) AccessControl() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accessControl
+}
+
+// SetGuestNetwork configures the guest network of a band on the mock router.
+// Bands without a guest network reject the guest actions as not supported.
+func (s *Server) SetGuestNetwork(network netgear.GuestNetwork) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.guest[network.Band] = network
+}
+
+// GuestNetwork reports the guest network of a band, as changed by the client
+func (s *Server) GuestNetwork(band netgear.Band) netgear.GuestNetwork {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.guest[band]
+}
+
+// SetTrafficMeter sets the traffic meter configuration and statistics
+// reported by the mock router. Until set, the traffic meter actions are
+// rejected as not supported.
+func (s *Server) SetTrafficMeter(options netgear.TrafficMeterOptions, meter netgear.TrafficMeter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.meterOptions = &options
+	s.meter = meter
+}
+
+// FailAuth causes the next n login attempts to be rejected, regardless of
+// the credentials provided.
+func (s *Server) FailAuth(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.authFailures = n
+}
+
+// TruncateResponses causes the next n responses to be cut off half way
+// through the payload.
+func (s *Server) TruncateResponses(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.truncations = n
+}
+
+// SetLatency delays every response by the given duration
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latency = d
+}
+
+// SetClockOffset skews the time reported by the mock router relative to the
+// local clock
+func (s *Server) SetClockOffset(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clockOffset = d
+}
+
+// Reboot simulates a router reboot. Any established session is lost and
+// connections are dropped without a response until the duration elapses.
+func (s *Server) Reboot(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.authenticated = false
+	s.rebootedUntil = time.Now().Add(d)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	latency := s.latency
+	rebooting := time.Now().Before(s.rebootedUntil)
+	s.mu.Unlock()
+
+	time.Sleep(latency)
+
+	if rebooting {
+		dropConnection(w)
+		return
+	}
+
+	// SOAPAction headers are formatted as <service urn>#<method>
+	action := strings.SplitN(r.Header.Get("SOAPAction"), "#", 2)
+	if len(action) != 2 {
+		http.Error(w, "Missing SOAPAction", http.StatusBadRequest)
+		return
+	}
+
+	service, method := action[0], action[1]
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code, payload := CodeNotSupported, ""
+	if s.supportsService(service) {
+		code, payload = s.call(serviceName(service), method, body)
+	}
+
+	layout := soapResponse
+	if serviceVersion(service) == 2 && code != CodeNotSupported {
+		layout = soapResponseV2
+	}
+
+	resp := fmt.Sprintf(layout, method, service, payload, code)
+
+	// Fixtures are only served for implemented service versions, so the
+	// client negotiates the version as it would with the recorded router
+	if fixture, ok := s.fixtures[serviceName(service)+"#"+method]; ok && s.supportsService(service) {
+		resp = fixture
+	}
+
+	if s.truncations > 0 {
+		s.truncations--
+		resp = resp[:len(resp)/2]
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	w.Header().Set("Date", time.Now().Add(s.clockOffset).UTC().Format(http.TimeFormat))
+	io.WriteString(w, resp)
+}
+
+// serviceName extracts the service name from a service URN
+func serviceName(urn string) string {
+	parts := strings.Split(urn, ":")
+	if len(parts) != 5 {
+		return ""
+	}
+
+	return parts[3]
+}
+
+// serviceVersion extracts the version from a service URN, zero when the URN
+// is malformed. URNs are formatted as
+// urn:NETGEAR-ROUTER:service:<service>:<version>
+func serviceVersion(urn string) int {
+	parts := strings.Split(urn, ":")
+	if len(parts) != 5 {
+		return 0
+	}
+
+	version, err := strconv.Atoi(parts[4])
+	if err != nil {
+		return 0
+	}
+
+	return version
+}
+
+// supportsService checks the requested service URN is a version implemented
+// by the mock router
+func (s *Server) supportsService(urn string) bool {
+	version := serviceVersion(urn)
+	if version == 0 {
+		return false
+	}
+
+	maxVersion, ok := s.versions[serviceName(urn)]
+	if !ok {
+		maxVersion = 1
+	}
+
+	return version <= maxVersion
+}
+
+func (s *Server) call(service, method string, body []byte) (code int, payload string) {
+	// Method names are only unique within a service
+	if method == "GetInfo" && service != "DeviceInfo" {
+		return CodeNotSupported, ""
+	}
+
+	switch method {
+	case "Authenticate":
+		code = s.authenticate(body)
+	case "SOAPLogout":
+		s.authenticated = false
+		code = CodeOK
+	case "GetInfo":
+		code, payload = s.routerInfo()
+	case "ConfigurationStarted", "ConfigurationFinished":
+		code = s.configuration(method)
+	case "GetBlockDeviceEnableStatus":
+		code, payload = s.accessControlStatus()
+	case "SetBlockDeviceEnable", "SetBlockDeviceByMAC":
+		code = s.setAccessControl(method, body)
+	case "GetAttachDevice":
+		code, payload = s.attachedDevices()
+	case "GetAttachDevice2":
+		code, payload = s.detailedDevices()
+	case "GetGuestAccessEnabled", "GetGuestAccessNetworkInfo":
+		code, payload = s.guestNetwork(netgear.Band2G, method)
+	case "Get5GGuestAccessEnabled", "Get5GGuestAccessNetworkInfo":
+		code, payload = s.guestNetwork(netgear.Band5G, method)
+	case "SetGuestAccessEnabled", "SetGuestAccessEnabled2":
+		code = s.setGuestAccess(netgear.Band2G, body)
+	case "Set5GGuestAccessEnabled", "Set5GGuestAccessEnabled2":
+		code = s.setGuestAccess(netgear.Band5G, body)
+	case "GetTrafficMeterOptions":
+		code, payload = s.trafficMeterOptions()
+	case "GetTrafficMeterStatistics":
+		code, payload = s.trafficMeterStatistics()
+	default:
+		code = CodeNotSupported
+	}
+
+	return code, payload
+}
+
+func (s *Server) authenticate(body []byte) int {
+	type soapAuthenticate struct {
+		Username string `xml:"Body>Authenticate>NewUsername"`
+		Password string `xml:"Body>Authenticate>NewPassword"`
+	}
+
+	auth := soapAuthenticate{}
+	if err := xml.Unmarshal(body, &auth); err != nil {
+		return CodeUnauthorized
+	}
+
+	if s.authFailures > 0 {
+		s.authFailures--
+		return CodeUnauthorized
+	}
+
+	if auth.Username != s.Username || auth.Password != s.Password {
+		return CodeUnauthorized
+	}
+
+	s.authenticated = true
+
+	return CodeOK
+}
+
+func (s *Server) configuration(method string) int {
+	if !s.authenticated {
+		return CodeUnauthorized
+	}
+
+	s.configuring = method == "ConfigurationStarted"
+
+	return CodeOK
+}
+
+func (s *Server) accessControlStatus() (int, string) {
+	if !s.authenticated {
+		return CodeUnauthorized, ""
+	}
+
+	enabled := 0
+	if s.accessControl {
+		enabled = 1
+	}
+
+	return CodeOK, fmt.Sprintf("<NewBlockDeviceEnable>%d</NewBlockDeviceEnable>\n", enabled)
+}
+
+func (s *Server) setAccessControl(method string, body []byte) int {
+	if !s.authenticated {
+		return CodeUnauthorized
+	}
+
+	// Changes are rejected outside of a configuration transaction
+	if !s.configuring {
+		return CodeUnauthorized
+	}
+
+	type soapParams struct {
+		Enable       string `xml:"Body>SetBlockDeviceEnable>NewBlockDeviceEnable"`
+		AllowOrBlock string `xml:"Body>SetBlockDeviceByMAC>NewAllowOrBlock"`
+		MAC          string `xml:"Body>SetBlockDeviceByMAC>NewMACAddress"`
+	}
+
+	params := soapParams{}
+	if err := xml.Unmarshal(body, &params); err != nil {
+		return CodeNotSupported
+	}
+
+	if method == "SetBlockDeviceEnable" {
+		s.accessControl = params.Enable == "1"
+		return CodeOK
+	}
+
+	s.blocked[strings.ToUpper(params.MAC)] = params.AllowOrBlock == "Block"
+
+	return CodeOK
+}
+
+func (s *Server) guestNetwork(band netgear.Band, method string) (int, string) {
+	if !s.authenticated {
+		return CodeUnauthorized, ""
+	}
+
+	network, ok := s.guest[band]
+	if !ok {
+		return CodeNotSupported, ""
+	}
+
+	if strings.HasSuffix(method, "NetworkInfo") {
+		return CodeOK, fmt.Sprintf("<NewSSID>%s</NewSSID>\n", xmlEscape(network.SSID))
+	}
+
+	enabled := 0
+	if network.Enabled {
+		enabled = 1
+	}
+
+	return CodeOK, fmt.Sprintf("<NewGuestAccessEnabled>%d</NewGuestAccessEnabled>\n", enabled)
+}
+
+func (s *Server) setGuestAccess(band netgear.Band, body []byte) int {
+	if !s.authenticated {
+		return CodeUnauthorized
+	}
+
+	// Changes are rejected outside of a configuration transaction
+	if !s.configuring {
+		return CodeUnauthorized
+	}
+
+	network, ok := s.guest[band]
+	if !ok {
+		return CodeNotSupported
+	}
+
+	// Each version of the set action names its element differently
+	type soapParams struct {
+		Body struct {
+			Action struct {
+				Enabled string `xml:"NewGuestAccessEnabled"`
+			} `xml:",any"`
+		} `xml:"Body"`
+	}
+
+	params := soapParams{}
+	if err := xml.Unmarshal(body, &params); err != nil {
+		return CodeNotSupported
+	}
+
+	network.Enabled = params.Body.Action.Enabled == "1"
+	s.guest[band] = network
+
+	return CodeOK
+}
+
+func (s *Server) trafficMeterOptions() (int, string) {
+	if !s.authenticated {
+		return CodeUnauthorized, ""
+	}
+
+	o := s.meterOptions
+	if o == nil {
+		return CodeNotSupported, ""
+	}
+
+	return CodeOK, fmt.Sprintf(
+		"<NewControlOption>%s</NewControlOption>\n"+
+			"<NewMonthlyLimit>%.0f</NewMonthlyLimit>\n"+
+			"<RestartHour>%02d</RestartHour>\n"+
+			"<RestartMinute>%02d</RestartMinute>\n"+
+			"<RestartDay>%02d</RestartDay>\n",
+		xmlEscape(o.ControlOption), o.MonthlyLimit, o.RestartHour, o.RestartMinute, o.RestartDay,
+	)
+}
+
+func (s *Server) trafficMeterStatistics() (int, string) {
+	if !s.authenticated {
+		return CodeUnauthorized, ""
+	}
+
+	if s.meterOptions == nil {
+		return CodeNotSupported, ""
+	}
+
+	b := &strings.Builder{}
+
+	for _, period := range []struct {
+		name   string
+		stats  netgear.TrafficPeriod
+		hasAvg bool
+	}{
+		{"Today", s.meter.Today, false},
+		{"Yesterday", s.meter.Yesterday, false},
+		{"Week", s.meter.Week, true},
+		{"Month", s.meter.Month, true},
+		{"LastMonth", s.meter.LastMonth, true},
+	} {
+		p := period.stats
+
+		upload := fmt.Sprintf("%.2f", p.Upload)
+		download := fmt.Sprintf("%.2f", p.Download)
+
+		// Periods longer than a day include the daily average
+		if period.hasAvg {
+			upload += fmt.Sprintf("/%.2f", p.AvgUpload)
+			download += fmt.Sprintf("/%.2f", p.AvgDownload)
+		}
+
+		connTime := fmt.Sprintf("%d:%02d", int(p.ConnectionTime.Hours()), int(p.ConnectionTime.Minutes())%60)
+
+		fmt.Fprintf(b, "<New%sConnectionTime>%s</New%[1]sConnectionTime>\n", period.name, connTime)
+		fmt.Fprintf(b, "<New%sUpload>%s</New%[1]sUpload>\n", period.name, upload)
+		fmt.Fprintf(b, "<New%sDownload>%s</New%[1]sDownload>\n", period.name, download)
+	}
+
+	return CodeOK, b.String()
+}
+
+func (s *Server) routerInfo() (int, string) {
+	if !s.authenticated {
+		return CodeUnauthorized, ""
+	}
+
+	b := &strings.Builder{}
+	enc := xml.NewEncoder(b)
+	enc.Encode(s.info)
+	enc.Flush()
+
+	// The encoded info is wrapped in a RouterInfo element, only the fields
+	// are included in the response.
+	info := strings.TrimSuffix(strings.TrimPrefix(b.String(), "<RouterInfo>"), "</RouterInfo>")
+
+	return CodeOK, info + "\n"
+}
+
+func (s *Server) attachedDevices() (int, string) {
+	if !s.authenticated {
+		return CodeUnauthorized, ""
+	}
+
+	return CodeOK, fmt.Sprintf(
+		"<NewAttachDevice>%s</NewAttachDevice>\n",
+		FormatDevices(s.devices),
+	)
+}
+
+func (s *Server) detailedDevices() (int, string) {
+	if !s.authenticated {
+		return CodeUnauthorized, ""
+	}
+
+	return CodeOK, fmt.Sprintf(
+		"<NewAttachDevice>%s</NewAttachDevice>\n",
+		FormatDetailedDevices(s.devices),
+	)
+}
+
+// FormatDevices encodes a list of devices in the format returned by the
+// router for the GetAttachDevice action.
+func FormatDevices(devices []netgear.AttachedDevice) string {
+	devStrs := make([]string, len(devices)+1)
+	devStrs[0] = strconv.Itoa(len(devices))
+
+	for i, dev := range devices {
+		addrs := []string{}
+		if dev.IP != nil {
+			addrs = append(addrs, dev.IP.String())
+		}
+		for _, addr := range dev.IPv6 {
+			addrs = append(addrs, addr.String())
+		}
+
+		devStrs[i+1] = strings.Join([]string{
+			strconv.Itoa(i + 1),
+			strings.Join(addrs, ","),
+			dev.Name,
+			dev.MAC.String(),
+			dev.Type,
+			strconv.Itoa(dev.Signal),
+			strconv.Itoa(dev.LinkRate),
+			"Allow",
+		}, ";")
+	}
+
+	return xmlEscape(strings.Join(devStrs, "@"))
+}
+
+// FormatDetailedDevices encodes a list of devices in the format returned by
+// the router for the GetAttachDevice2 action.
+func FormatDetailedDevices(devices []netgear.AttachedDevice) string {
+	type soapDevice2 struct {
+		IP             string `xml:"IP"`
+		Name           string `xml:"Name"`
+		MAC            string `xml:"MAC"`
+		ConnectionType string `xml:"ConnectionType"`
+		SSID           string `xml:"SSID"`
+		LinkRate       int    `xml:"Linkspeed"`
+		Signal         int    `xml:"SignalStrength"`
+		Model          string `xml:"DeviceModel"`
+		Upload         string `xml:"Upload"`
+		Download       string `xml:"Download"`
+		AccessPoint    string `xml:"ConnAPMAC"`
+	}
+
+	b := &strings.Builder{}
+	enc := xml.NewEncoder(b)
+
+	for _, dev := range devices {
+		connType := dev.ConnectionType
+		if connType == "" {
+			connType = dev.Type
+		}
+
+		enc.EncodeElement(soapDevice2{
+			IP:             dev.IP.String(),
+			Name:           dev.Name,
+			MAC:            dev.MAC.String(),
+			ConnectionType: connType,
+			SSID:           dev.SSID,
+			LinkRate:       dev.LinkRate,
+			Signal:         dev.Signal,
+			Model:          dev.Model,
+			Upload:         strconv.FormatFloat(dev.Upload, 'f', 2, 64),
+			Download:       strconv.FormatFloat(dev.Download, 'f', 2, 64),
+			AccessPoint:    dev.AccessPoint.String(),
+		}, xml.StartElement{Name: xml.Name{Local: "Device"}})
+	}
+
+	enc.Flush()
+
+	return b.String()
+}
+
+func xmlEscape(s string) string {
+	b := &strings.Builder{}
+	xml.EscapeText(b, []byte(s))
+
+	return b.String()
+}
+
+// dropConnection closes the underlying connection without writing any
+// response, similar to a router that has gone away mid request.
+func dropConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+
+	conn.Close()
+}
@@ -0,0 +1,46 @@
+package netgeartest
+
+import (
+	"net"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+// Start starts a mock router accepting the username admin and the password
+// password. The server is closed once the test finishes.
+func Start(t testing.TB) *Server {
+	server := NewServer("admin", "password")
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// MustMAC parses a MAC address, failing the test when it is invalid
+func MustMAC(t testing.TB, s string) net.HardwareAddr {
+	t.Helper()
+
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return mac
+}
+
+// Device constructs a wireless device attached to the 5GHz band, to be
+// listed by the mock router using SetDevices
+func Device(t testing.TB, mac, ip, name string) netgear.AttachedDevice {
+	t.Helper()
+
+	return netgear.AttachedDevice{
+		IP:             net.ParseIP(ip),
+		Name:           name,
+		MAC:            MustMAC(t, mac),
+		Type:           "wireless",
+		Signal:         80,
+		LinkRate:       433,
+		ConnectionType: "5GHz",
+		SSID:           "home",
+	}
+}
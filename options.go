@@ -0,0 +1,167 @@
+package netgear
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithPinnedIP makes the client connect to the given IP address instead of
+// resolving the configured host. The host is still used in the Host header.
+func WithPinnedIP(ip net.IP) ClientOption {
+	return func(c *Client) {
+		c.dial.pinnedIP = ip
+	}
+}
+
+// WithResolver resolves the configured host using a custom resolver. This is
+// useful for hostnames such as routerlogin.net, which should be resolved
+// using the routers DNS server instead of the system resolver.
+func WithResolver(resolver *net.Resolver) ClientOption {
+	return func(c *Client) {
+		c.dial.resolver = resolver
+	}
+}
+
+// WithLocalAddr sends all SOAP traffic from the given local address. This is
+// needed on multi-homed hosts where the default route does not lead to the
+// router.
+func WithLocalAddr(ip net.IP) ClientOption {
+	return func(c *Client) {
+		c.dial.localAddr = ip
+	}
+}
+
+// WithInterface sends all SOAP traffic from the named network interface. The
+// interface address is looked up for each new connection, so interfaces with
+// dynamically assigned addresses are supported.
+func WithInterface(name string) ClientOption {
+	return func(c *Client) {
+		c.dial.iface = name
+	}
+}
+
+// dialConfig controls how connections to the router are established
+type dialConfig struct {
+	pinnedIP  net.IP
+	resolver  *net.Resolver
+	localAddr net.IP
+	iface     string
+	trust     TrustStore
+}
+
+func (d dialConfig) isDefault() bool {
+	return d.pinnedIP == nil && d.resolver == nil && d.localAddr == nil && d.iface == "" && d.trust == nil
+}
+
+// scheme is the URL scheme SOAP requests are made using
+func (d dialConfig) scheme() string {
+	if d.trust != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// localIP determines the local address connections should be made from, nil
+// when the default should be used
+func (d dialConfig) localIP() (net.IP, error) {
+	if d.iface == "" {
+		return d.localAddr, nil
+	}
+
+	iface, err := net.InterfaceByName(d.iface)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	// Prefer IPv4 addresses since that's what the router will be reachable
+	// through in nearly all cases.
+	var fallback net.IP
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+
+		if fallback == nil {
+			fallback = ipNet.IP
+		}
+	}
+
+	if fallback == nil {
+		return nil, fmt.Errorf("Interface %s has no usable addresses", d.iface)
+	}
+
+	return fallback, nil
+}
+
+// httpClient constructs the HTTP client used to make SOAP requests. The
+// default HTTP client is used when no dial options are configured.
+func (d dialConfig) httpClient() *http.Client {
+	if d.isDefault() {
+		return nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = d.dialContext
+
+	if d.trust != nil {
+		transport.DialTLSContext = d.dialTLSContext
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// dialContext connects to the router according to the dial options
+func (d dialConfig) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Resolver: d.resolver}
+
+	localIP, err := d.localIP()
+	if err != nil {
+		return nil, err
+	}
+
+	if localIP != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: localIP}
+	}
+
+	if d.pinnedIP != nil {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		addr = net.JoinHostPort(d.pinnedIP.String(), port)
+	}
+
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// Dial connects to the router on the given port the same way SOAP requests
+// do, from the configured interface or local address and using TLS when
+// trusting on first use
+func (c *Client) Dial(ctx context.Context, port int) (net.Conn, error) {
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(port))
+
+	if c.dial.trust != nil {
+		return c.dial.dialTLSContext(ctx, "tcp", addr)
+	}
+
+	return c.dial.dialContext(ctx, "tcp", addr)
+}
@@ -0,0 +1,113 @@
+package netgear
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ResponseParser rewrites the raw SOAP response of an action from unusual
+// firmware into the response the client expects, such as renaming elements
+// or reformatting values. The body is the full response envelope. Parsers
+// allow a small override for an exotic model to be shipped without changes
+// to the library.
+type ResponseParser func(action string, body []byte) ([]byte, error)
+
+var (
+	registryMu       sync.Mutex
+	parsers          = map[string]ResponseParser{}
+	registeredQuirks []Quirk
+)
+
+// RegisterParser registers a ResponseParser by name, to be selected by the
+// Parsers of a Quirk. Packages providing overrides usually register them,
+// along with the quirk selecting them, from init.
+func RegisterParser(name string, parser ResponseParser) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	parsers[name] = parser
+}
+
+// RegisterQuirk adds a quirk to the known quirks. Registered quirks take
+// precedence over the built in quirks affecting the same API.
+func RegisterQuirk(quirk Quirk) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registeredQuirks = append(registeredQuirks, quirk)
+}
+
+// WithModel sets the router model used to select the parsers of known
+// quirks. Otherwise the model is learned from the first successful call to
+// Info, and responses before then are parsed as usual.
+func WithModel(model string) ClientOption {
+	return func(c *Client) {
+		c.setModel(model)
+	}
+}
+
+// setModel selects the registered parsers of the quirks of the model
+func (c *Client) setModel(model string) {
+	quirks := ModelQuirks(model)
+	selected := map[soapAction]ResponseParser{}
+
+	registryMu.Lock()
+	for _, quirk := range quirks {
+		for action, name := range quirk.Parsers {
+			if parser, ok := parsers[name]; ok {
+				selected[soapAction(action)] = parser
+			}
+		}
+	}
+	registryMu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.model = model
+	c.parsers = selected
+}
+
+// learnModel sets the model from the router info, unless already known
+func (c *Client) learnModel(model string) {
+	c.mu.Lock()
+	known := c.model != ""
+	c.mu.Unlock()
+
+	if !known {
+		c.setModel(model)
+	}
+}
+
+// parse applies the parser selected for the action to the response body
+func (c *Client) parse(action soapAction, resp *http.Response) error {
+	c.mu.Lock()
+	parser := c.parsers[action]
+	c.mu.Unlock()
+
+	if parser == nil {
+		return nil
+	}
+
+	return rewriteBody(parser, action, resp)
+}
+
+// rewriteBody replaces the response body with the output of the parser
+func rewriteBody(parser ResponseParser, action soapAction, resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if body, err = parser(string(action), body); err != nil {
+		return fmt.Errorf("Parser for %s failed: %w", action, err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return nil
+}
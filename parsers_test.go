@@ -0,0 +1,62 @@
+package netgear_test
+
+import (
+	"bytes"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+func replaceParser(old, new string) netgear.ResponseParser {
+	return func(action string, body []byte) ([]byte, error) {
+		return bytes.Replace(body, []byte(old), []byte(new), 1), nil
+	}
+}
+
+func TestQuirkParsers(t *testing.T) {
+	netgear.RegisterParser("test-info", replaceParser("V1.0.11.116_10.2.100", "patched"))
+	netgear.RegisterParser("test-devices", replaceParser("phone", "patched-phone"))
+
+	// Both quirks only select parsers for the same API, and are registered
+	// with lower case prefixes
+	netgear.RegisterQuirk(netgear.Quirk{
+		Models:  []string{"zz"},
+		API:     "Devices",
+		Parsers: map[string]string{soapconst.DeviceInfoGetInfo: "test-info"},
+	})
+	netgear.RegisterQuirk(netgear.Quirk{
+		Models:  []string{"zz"},
+		API:     "Devices",
+		Parsers: map[string]string{soapconst.DeviceInfoGetAttachDevice: "test-devices"},
+	})
+
+	if quirks := netgear.ModelQuirks("ZZ1000"); len(quirks) != 2 {
+		t.Fatalf("Expected both quirks to apply, got %d", len(quirks))
+	}
+
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	client := server.Client(netgear.WithModel("ZZ1000"))
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := client.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Firmware != "patched" {
+		t.Errorf("Expected the info parser to apply, got firmware %q", info.Firmware)
+	}
+
+	devices, err := client.Devices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 || devices[0].Name != "patched-phone" {
+		t.Errorf("Expected the devices parser to apply, got %+v", devices)
+	}
+}
@@ -0,0 +1,142 @@
+package netgear
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+const (
+	blockDeviceAction             soapAction = soapconst.DeviceConfigSetBlockDeviceByMAC
+	blockDeviceEnableAction       soapAction = soapconst.DeviceConfigSetBlockDeviceEnable
+	blockDeviceEnableStatusAction soapAction = soapconst.DeviceConfigGetBlockDeviceEnableStatus
+)
+
+// ErrPauseNotSupported is returned when pausing a device on a router which
+// can not block devices by MAC address through access control
+var ErrPauseNotSupported = errors.New("Router does not support blocking devices by MAC address")
+
+// PauseInternet blocks a device from accessing the internet, while it remains
+// attached to the network. The routers access control is enabled if it is
+// not already, which otherwise ignores blocked devices, and disabled again
+// once ResumeInternet leaves no device paused by the client. Devices are
+// blocked by MAC address, which all routers implementing access control
+// support. ErrPauseNotSupported is returned by routers without it.
+func (c *Client) PauseInternet(mac net.HardwareAddr) error {
+	return c.PauseInternetContext(context.Background(), mac)
+}
+
+// PauseInternetContext is PauseInternet, joining the configuration
+// transaction of ctx when made within Configure
+func (c *Client) PauseInternetContext(ctx context.Context, mac net.HardwareAddr) error {
+	enabled, err := c.blockDeviceEnabled()
+	if err != nil {
+		return pauseError(err)
+	}
+
+	err = c.configure(ctx, func(ctx context.Context) error {
+		if !enabled {
+			params := []SOAPParam{{"NewBlockDeviceEnable", "1"}}
+			if err := c.callContext(ctx, "enable access control", blockDeviceEnableAction, params, nil); err != nil {
+				return err
+			}
+
+			c.mu.Lock()
+			c.pauseEnabled = true
+			c.mu.Unlock()
+		}
+
+		if err := c.setBlockDevice(ctx, mac, "Block"); err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		if c.paused == nil {
+			c.paused = map[string]bool{}
+		}
+		c.paused[mac.String()] = true
+		c.mu.Unlock()
+
+		return nil
+	})
+
+	return pauseError(err)
+}
+
+// ResumeInternet allows a device paused with PauseInternet to access the
+// internet again. Access control is disabled when it was enabled to pause
+// devices and none remain paused. Devices paused by other clients, such as
+// an earlier run of a command, are not known, so access control is left as
+// is after resuming them.
+func (c *Client) ResumeInternet(mac net.HardwareAddr) error {
+	return c.ResumeInternetContext(context.Background(), mac)
+}
+
+// ResumeInternetContext is ResumeInternet, joining the configuration
+// transaction of ctx when made within Configure
+func (c *Client) ResumeInternetContext(ctx context.Context, mac net.HardwareAddr) error {
+	err := c.configure(ctx, func(ctx context.Context) error {
+		if err := c.setBlockDevice(ctx, mac, "Allow"); err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		delete(c.paused, mac.String())
+		restore := c.pauseEnabled && len(c.paused) == 0
+		c.mu.Unlock()
+
+		if !restore {
+			return nil
+		}
+
+		params := []SOAPParam{{"NewBlockDeviceEnable", "0"}}
+		if err := c.callContext(ctx, "disable access control", blockDeviceEnableAction, params, nil); err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		c.pauseEnabled = false
+		c.mu.Unlock()
+
+		return nil
+	})
+
+	return pauseError(err)
+}
+
+// pauseError reports routers rejecting the access control actions as not
+// supported with ErrPauseNotSupported
+func pauseError(err error) error {
+	respErr := &ResponseError{}
+	if errors.As(err, &respErr) && respErr.Code == codeNotSupported {
+		return fmt.Errorf("%w: %w", ErrPauseNotSupported, err)
+	}
+
+	return err
+}
+
+func (c *Client) setBlockDevice(ctx context.Context, mac net.HardwareAddr, allowOrBlock string) error {
+	params := []SOAPParam{
+		{"NewAllowOrBlock", allowOrBlock},
+		{"NewMACAddress", strings.ToUpper(mac.String())},
+	}
+
+	return c.callContext(ctx, strings.ToLower(allowOrBlock)+" device", blockDeviceAction, params, nil)
+}
+
+func (c *Client) blockDeviceEnabled() (bool, error) {
+	type soapEnvelope struct {
+		Enabled string `xml:"Body>GetBlockDeviceEnableStatusResponse>NewBlockDeviceEnable"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := c.call("get access control status", blockDeviceEnableStatusAction, nil, &envelope); err != nil {
+		return false, err
+	}
+
+	return envelope.Enabled == "1", nil
+}
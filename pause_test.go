@@ -0,0 +1,87 @@
+package netgear_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+func TestPauseInternetRestoresAccessControl(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	phone := netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")
+	laptop := netgeartest.MustMAC(t, "aa:bb:cc:00:00:02")
+
+	for _, mac := range []net.HardwareAddr{phone, laptop} {
+		if err := client.PauseInternet(mac); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !server.Blocked(phone) || !server.Blocked(laptop) {
+		t.Fatal("Expected both devices to be paused")
+	}
+
+	if err := client.ResumeInternet(phone); err != nil {
+		t.Fatal(err)
+	}
+
+	if !server.AccessControl() || !server.Blocked(laptop) {
+		t.Error("Expected access control to remain enabled while the laptop is paused")
+	}
+
+	if err := client.ResumeInternet(laptop); err != nil {
+		t.Fatal(err)
+	}
+
+	if server.AccessControl() {
+		t.Error("Expected access control to be disabled once no devices are paused")
+	}
+}
+
+func TestPauseInternetKeepsAccessControl(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetAccessControl(true)
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	phone := netgeartest.MustMAC(t, "aa:bb:cc:00:00:01")
+
+	if err := client.PauseInternet(phone); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ResumeInternet(phone); err != nil {
+		t.Fatal(err)
+	}
+
+	if !server.AccessControl() {
+		t.Error("Expected access control enabled beforehand to be left enabled")
+	}
+}
+
+func TestPauseInternetNotSupported(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetServiceVersion(soapconst.DeviceConfig, 0)
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := client.PauseInternet(netgeartest.MustMAC(t, "aa:bb:cc:00:00:01"))
+	if !errors.Is(err, netgear.ErrPauseNotSupported) {
+		t.Errorf("Expected ErrPauseNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+package netgear
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Person is someone whose presence is tracked through one or more of their
+// devices. Devices are matched by MAC address, or by hostname for devices
+// which randomize their MAC address (see AttachedDevice.IsRandomized), or by
+// the identity determined by the Presence Identity function.
+type Person struct {
+	Name       string
+	Devices    []net.HardwareAddr
+	Hostnames  []string
+	Identities []string
+}
+
+type personState struct {
+	person   Person
+	home     bool
+	attached map[string]bool
+	leaving  *time.Timer
+
+	// devices are the MAC addresses of every device matched to the person,
+	// including those matched by hostname or identity
+	devices map[string]net.HardwareAddr
+
+	// departure identifies the most recently scheduled departure, so a
+	// stale timer firing after being cancelled is ignored
+	departure int
+}
+
+// PersonState is the presence of a person saved with the watcher state, see
+// WithPresence. Attached lists the identities of the persons attached
+// devices and Devices the MAC addresses matched to them.
+type PersonState struct {
+	Home     bool
+	Attached []string
+	Devices  []string
+}
+
+// Presence tracks which people are home based on their devices attached to
+// the router. Attach it to a Watcher using Subscribe with replay enabled.
+type Presence struct {
+	// LeaveDelay is how long all of a persons devices must be detached before
+	// they are considered to have left. Phones frequently drop off the
+	// network while idle, which would otherwise be reported as leaving.
+	LeaveDelay time.Duration
+
+	// OnArrive is called when the first of a persons devices attaches
+	OnArrive func(Person)
+
+	// OnLeave is called once all of a persons devices have been detached for
+	// the LeaveDelay
+	OnLeave func(Person)
+
+	// Identity determines which attached devices are the same device, and
+	// should match the identity the watcher tracks devices by (see
+	// WithIdentity). Devices are identified by MAC address when nil.
+	Identity IdentityFunc
+
+	// Leases distinguishes people whose devices are asleep from people who
+	// have gone, see State. It should be subscribed to the same watcher.
+	Leases *LeaseTracker
+
+	mu         sync.Mutex
+	people     map[string]*personState
+	byMAC      map[string]*personState
+	byHostname map[string]*personState
+	byIdentity map[string]*personState
+}
+
+// NewPresence constructs a Presence tracking the given people
+func NewPresence(people ...Person) *Presence {
+	p := &Presence{
+		people:     map[string]*personState{},
+		byMAC:      map[string]*personState{},
+		byHostname: map[string]*personState{},
+		byIdentity: map[string]*personState{},
+	}
+
+	for _, person := range people {
+		state := &personState{
+			person:   person,
+			attached: map[string]bool{},
+			devices:  map[string]net.HardwareAddr{},
+		}
+		p.people[person.Name] = state
+
+		for _, mac := range person.Devices {
+			p.byMAC[mac.String()] = state
+			state.devices[mac.String()] = mac
+		}
+
+		for _, hostname := range person.Hostnames {
+			p.byHostname[strings.ToLower(hostname)] = state
+		}
+
+		for _, identity := range person.Identities {
+			p.byIdentity[identity] = state
+		}
+	}
+
+	return p
+}
+
+// Listener is the DeviceListener updating the tracked presence. Errors
+// polling the router are ignored, people are left in their last known state.
+func (p *Presence) Listener() DeviceListener {
+	return func(change *ChangedDevice, err error) {
+		if err != nil {
+			return
+		}
+
+		p.apply(change)
+	}
+}
+
+func (p *Presence) apply(change *ChangedDevice) {
+	p.mu.Lock()
+
+	identity := p.identity(change.Device)
+
+	state, ok := p.byMAC[change.Device.MAC.String()]
+	if !ok {
+		state, ok = p.byHostname[strings.ToLower(change.Device.Name)]
+	}
+	if !ok {
+		state, ok = p.byIdentity[identity]
+	}
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+
+	state.devices[change.Device.MAC.String()] = change.Device.MAC
+
+	var arrived bool
+
+	switch change.Change {
+	case DeviceAdded, DeviceSeen:
+		state.attached[identity] = true
+
+		// A device returning within the leave delay cancels the pending
+		// departure, the person never left.
+		if state.leaving != nil {
+			state.leaving.Stop()
+			state.leaving = nil
+		}
+
+		if !state.home {
+			state.home = true
+			arrived = true
+		}
+	case DeviceRemoved:
+		delete(state.attached, identity)
+
+		if len(state.attached) == 0 && state.home && state.leaving == nil {
+			state.departure++
+			departure := state.departure
+			state.leaving = time.AfterFunc(p.LeaveDelay, func() { p.leave(state, departure) })
+		}
+	}
+
+	p.mu.Unlock()
+
+	if arrived && p.OnArrive != nil {
+		p.OnArrive(state.person)
+	}
+}
+
+func (p *Presence) identity(dev AttachedDevice) string {
+	if p.Identity == nil {
+		return IdentityMAC(dev)
+	}
+
+	return p.Identity(dev)
+}
+
+func (p *Presence) leave(state *personState, departure int) {
+	p.mu.Lock()
+
+	// The departure may have been cancelled after the timer fired but before
+	// the lock was acquired.
+	left := state.leaving != nil && state.departure == departure
+	if left {
+		state.home = false
+		state.leaving = nil
+	}
+
+	p.mu.Unlock()
+
+	if left && p.OnLeave != nil {
+		p.OnLeave(state.person)
+	}
+}
+
+// Home reports if the named person is home
+func (p *Presence) Home(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, ok := p.people[name]
+
+	return ok && state.home
+}
+
+// State classifies the named person as online when any of their devices are
+// attached, sleeping when their devices have detached but still hold a DHCP
+// lease, and otherwise gone. Without Leases people are only considered
+// sleeping while their departure is pending the LeaveDelay.
+func (p *Presence) State(name string) DeviceState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	state, ok := p.people[name]
+	switch {
+	case !ok:
+		return StateGone
+	case len(state.attached) > 0:
+		return StateOnline
+	case p.Leases == nil && state.home:
+		return StateSleeping
+	case p.Leases == nil:
+		return StateGone
+	}
+
+	for _, mac := range state.devices {
+		if p.Leases.State(mac) != StateGone {
+			return StateSleeping
+		}
+	}
+
+	return StateGone
+}
+
+// AnyoneHome reports if any tracked person is home
+func (p *Presence) AnyoneHome() bool {
+	return len(p.PeopleHome()) > 0
+}
+
+// PeopleHome lists the names of the people who are home, in order
+func (p *Presence) PeopleHome() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	home := []string{}
+	for name, state := range p.people {
+		if state.home {
+			home = append(home, name)
+		}
+	}
+
+	sort.Strings(home)
+
+	return home
+}
+
+// snapshot captures the presence of every tracked person
+func (p *Presence) snapshot() map[string]PersonState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	states := make(map[string]PersonState, len(p.people))
+	for name, state := range p.people {
+		saved := PersonState{Home: state.home}
+
+		for identity := range state.attached {
+			saved.Attached = append(saved.Attached, identity)
+		}
+
+		for mac := range state.devices {
+			saved.Devices = append(saved.Devices, mac)
+		}
+
+		sort.Strings(saved.Attached)
+		sort.Strings(saved.Devices)
+
+		states[name] = saved
+	}
+
+	return states
+}
+
+// restore replaces the presence of the tracked people without calling
+// OnArrive or OnLeave. People home without attached devices were pending
+// departure, which starts again from the LeaveDelay.
+func (p *Presence) restore(states map[string]PersonState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, saved := range states {
+		state, ok := p.people[name]
+		if !ok {
+			continue
+		}
+
+		if state.leaving != nil {
+			state.leaving.Stop()
+			state.leaving = nil
+		}
+
+		state.home = saved.Home
+
+		state.attached = make(map[string]bool, len(saved.Attached))
+		for _, identity := range saved.Attached {
+			state.attached[identity] = true
+		}
+
+		for _, mac := range saved.Devices {
+			if hwAddr, err := net.ParseMAC(mac); err == nil {
+				state.devices[hwAddr.String()] = hwAddr
+			}
+		}
+
+		if state.home && len(state.attached) == 0 {
+			state.departure++
+			departure := state.departure
+			state.leaving = time.AfterFunc(p.LeaveDelay, func() { p.leave(state, departure) })
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package netgear
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"net"
+	"strings"
+)
+
+// Privacy redacts device identifiers, so presence data can be shared without
+// exposing the identities of household devices
+type Privacy struct {
+	// Key is the HMAC key MAC addresses are hashed with. The same key always
+	// produces the same hashed address, so devices remain distinguishable.
+	Key []byte
+
+	// NameLength is the number of characters device names are truncated to.
+	// Zero removes names entirely.
+	NameLength int
+}
+
+// MAC hashes a MAC address. The hash is truncated to the length of the
+// address and marked as locally administered, so it may be used anywhere a
+// MAC address is expected.
+func (p *Privacy) MAC(mac net.HardwareAddr) net.HardwareAddr {
+	if p == nil {
+		return mac
+	}
+
+	h := hmac.New(sha256.New, p.Key)
+	h.Write(mac)
+
+	hashed := net.HardwareAddr(h.Sum(nil)[:len(mac)])
+	if len(hashed) > 0 {
+		hashed[0] = hashed[0]&0xfc | 0x02
+	}
+
+	return hashed
+}
+
+// Name truncates a device name
+func (p *Privacy) Name(name string) string {
+	if p == nil {
+		return name
+	}
+
+	runes := []rune(name)
+	if len(runes) <= p.NameLength {
+		return name
+	}
+
+	return string(runes[:p.NameLength])
+}
+
+// WithPrivacy makes FormatMAC, FormatName, FormatLabel, FormatIP, and
+// FormatMeta redact device identifiers
+func WithPrivacy(p *Privacy) ClientOption {
+	return func(c *Client) {
+		c.privacy = p
+	}
+}
+
+// FormatName renders a device name for output, truncating it when the client
+// is configured with Privacy
+func (c *Client) FormatName(name string) string {
+	return c.privacy.Name(name)
+}
+
+// FormatLabel renders the label of a device for output. When the client is
+// configured with Privacy, labels built from the MAC address, such as the
+// NameChain fallback and VendorNames, end with the last octets of the hashed
+// address instead, before the label is truncated.
+func (c *Client) FormatLabel(dev AttachedDevice) string {
+	if c.privacy == nil {
+		return dev.Label
+	}
+
+	label := dev.Label
+	if suffix := macSuffix(dev.MAC); strings.HasSuffix(label, suffix) {
+		label = strings.TrimSuffix(label, suffix) + macSuffix(c.privacy.MAC(dev.MAC))
+	}
+
+	return c.privacy.Name(label)
+}
+
+// FormatIP renders a device IP address for output, empty when the client is
+// configured with Privacy, as addresses reserved for a device identify it as
+// well as its MAC address
+func (c *Client) FormatIP(ip net.IP) string {
+	if c.privacy != nil || ip == nil {
+		return ""
+	}
+
+	return ip.String()
+}
+
+// FormatMeta renders device metadata for output, empty when the client is
+// configured with Privacy, as owners, notes, and tags name the people the
+// devices belong to
+func (c *Client) FormatMeta(meta DeviceMeta) DeviceMeta {
+	if c.privacy != nil {
+		return DeviceMeta{}
+	}
+
+	return meta
+}
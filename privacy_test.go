@@ -0,0 +1,38 @@
+package netgear_test
+
+import (
+	"strings"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func TestFormatLabelPrivacy(t *testing.T) {
+	privacy := &netgear.Privacy{Key: []byte("key"), NameLength: 32}
+	client := netgear.NewClient("router", "admin", "password", netgear.WithPrivacy(privacy))
+
+	dev := netgeartest.Device(t, "aa:bb:cc:00:ee:ff", "192.168.1.2", "")
+	dev.Label = netgear.DefaultNameChain.Label(dev)
+
+	hashed := privacy.MAC(dev.MAC)
+	expected := "Device " + hashed[len(hashed)-2:].String()
+
+	if label := client.FormatLabel(dev); label != expected {
+		t.Errorf("Expected label %q, got %q", expected, label)
+	}
+
+	if label := client.FormatLabel(dev); strings.Contains(label, "ee:ff") {
+		t.Errorf("Label %q exposes the MAC address", label)
+	}
+
+	if ip := client.FormatIP(dev.IP); ip != "" {
+		t.Errorf("Expected the IP to be omitted, got %q", ip)
+	}
+
+	plain := netgear.NewClient("router", "admin", "password")
+
+	if label := plain.FormatLabel(dev); label != "Device ee:ff" {
+		t.Errorf("Expected the label unchanged without privacy, got %q", label)
+	}
+}
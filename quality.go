@@ -0,0 +1,92 @@
+package netgear
+
+import "math"
+
+// QualityThresholds configure how the connection quality of a device is
+// scored. Signal strength is a percentage and link rates are in Mbps.
+type QualityThresholds struct {
+	// Signals at or above GoodSignal score full marks, while signals at or
+	// below PoorSignal score nothing
+	GoodSignal int
+	PoorSignal int
+
+	// Link rates at or above GoodLinkRate score full marks, while link rates
+	// at or below PoorLinkRate score nothing
+	GoodLinkRate int
+	PoorLinkRate int
+
+	// Band24Penalty is deducted from devices connected using the congested
+	// 2.4GHz band
+	Band24Penalty int
+}
+
+// DefaultQualityThresholds are used unless configured with
+// WithQualityThresholds
+var DefaultQualityThresholds = QualityThresholds{
+	GoodSignal:    70,
+	PoorSignal:    30,
+	GoodLinkRate:  300,
+	PoorLinkRate:  24,
+	Band24Penalty: 10,
+}
+
+// WithQualityThresholds configures how the Quality of attached devices is
+// scored
+func WithQualityThresholds(t QualityThresholds) ClientOption {
+	return func(c *Client) {
+		c.quality = &t
+	}
+}
+
+// Score rates the connection quality of a device from 0 to 100. Wired
+// devices always score 100. The score is zero when neither the signal nor
+// link rate is reported.
+func (t QualityThresholds) Score(d AttachedDevice) int {
+	if d.Type == "wired" {
+		return 100
+	}
+
+	scale := func(value, poor, good int) float64 {
+		if good <= poor {
+			return 1
+		}
+		return math.Max(0, math.Min(1, float64(value-poor)/float64(good-poor)))
+	}
+
+	var score float64
+
+	switch {
+	case d.Signal > 0 && d.LinkRate > 0:
+		score = 0.6*scale(d.Signal, t.PoorSignal, t.GoodSignal) + 0.4*scale(d.LinkRate, t.PoorLinkRate, t.GoodLinkRate)
+	case d.Signal > 0:
+		score = scale(d.Signal, t.PoorSignal, t.GoodSignal)
+	case d.LinkRate > 0:
+		score = scale(d.LinkRate, t.PoorLinkRate, t.GoodLinkRate)
+	default:
+		return 0
+	}
+
+	quality := int(math.Round(score * 100))
+
+	if d.Band() == Band2G {
+		quality -= t.Band24Penalty
+	}
+
+	if quality < 0 {
+		return 0
+	}
+
+	return quality
+}
+
+// scoreDevices sets the Quality of each device using the clients thresholds
+func (c *Client) scoreDevices(devices []AttachedDevice) {
+	thresholds := DefaultQualityThresholds
+	if c.quality != nil {
+		thresholds = *c.quality
+	}
+
+	for i := range devices {
+		devices[i].Quality = thresholds.Score(devices[i])
+	}
+}
@@ -0,0 +1,73 @@
+package netgear
+
+import "strings"
+
+// Quirk is a known difference in the behavior of a router model
+type Quirk struct {
+	// Models are the model name prefixes the quirk applies to, matched
+	// case insensitively
+	Models []string
+
+	// API is the library API affected by the quirk
+	API string
+
+	// Support overrides the probed support of the API, Unknown leaves it
+	// to be probed
+	Support Support
+
+	// Parsers select registered ResponseParsers by name for the responses
+	// of actions, keyed by action such as soapconst.DeviceInfoGetAttachDevice
+	Parsers map[string]string
+
+	Note string
+}
+
+// knownQuirks lists router model quirks. Contributions are welcome, please
+// include the firmware versions the quirk was observed on in the note.
+var knownQuirks = []Quirk{
+	{
+		Models:  []string{"RBR", "RBS", "RBK", "SRR", "SRS", "SRK"},
+		API:     "DetailedDevices",
+		Support: Supported,
+		Note:    "Mesh systems report the satellite each device is attached through",
+	},
+}
+
+// ModelQuirks gets the known quirks of a router model, including registered
+// quirks. Several quirks may affect the same API, such as quirks which only
+// select parsers. Registered quirks follow the built in quirks, so they take
+// precedence when applied in order.
+func ModelQuirks(model string) []Quirk {
+	registryMu.Lock()
+	all := append(append([]Quirk{}, knownQuirks...), registeredQuirks...)
+	registryMu.Unlock()
+
+	model = strings.ToUpper(model)
+	quirks := []Quirk{}
+
+	for _, quirk := range all {
+		for _, prefix := range quirk.Models {
+			if strings.HasPrefix(model, strings.ToUpper(prefix)) {
+				quirks = append(quirks, quirk)
+				break
+			}
+		}
+	}
+
+	return quirks
+}
+
+// quirkSupport finds the support of an API overridden by the quirks, the
+// last overriding quirk taking precedence
+func quirkSupport(quirks []Quirk, api string) (Quirk, bool) {
+	var found Quirk
+	var ok bool
+
+	for _, quirk := range quirks {
+		if quirk.API == api && quirk.Support != Unknown {
+			found, ok = quirk, true
+		}
+	}
+
+	return found, ok
+}
@@ -0,0 +1,178 @@
+package netgear
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultRolloutPoll is how often the router is queried while waiting for
+// devices to reattach, unless the Rollout sets a Poll interval
+const DefaultRolloutPoll = 5 * time.Second
+
+// Rollout describes a risky settings change, such as changing the wireless
+// channel or security mode, which may cause devices to drop off the network.
+type Rollout struct {
+	// Apply makes the settings change. It should not return until the change
+	// has been applied by the router, otherwise devices may be seen as
+	// reattached before they have dropped off.
+	Apply func() error
+
+	// Rollback restores the settings from before the change, typically from
+	// a snapshot taken before applying it
+	Rollback func() error
+
+	// Window is how long devices have to reattach after the change is applied
+	Window time.Duration
+
+	// Poll is how often the router is queried while waiting for devices to
+	// reattach, DefaultRolloutPoll when zero
+	Poll time.Duration
+
+	// MaxMissing is the fraction of previously attached devices which may
+	// fail to reattach before the change is rolled back
+	MaxMissing float64
+}
+
+// RolloutResult reports the outcome of a rollout. Devices are ordered by MAC
+// address.
+type RolloutResult struct {
+	Before     []AttachedDevice
+	Missing    []AttachedDevice
+	RolledBack bool
+}
+
+// ErrNotReattached is the failure of a device which did not reattach within
+// the window of a rollout
+var ErrNotReattached = errors.New("Device did not reattach")
+
+// ApplyWithRollback applies the rollout settings change and watches for the
+// devices attached beforehand to reattach. If too many devices fail to
+// return within the window the change is rolled back. When any device does
+// not reattach a MultiError is returned, failing those devices with
+// ErrNotReattached, and the roll back when it failed. The change is kept
+// unless the result is RolledBack.
+func (c *Client) ApplyWithRollback(r Rollout) (*RolloutResult, error) {
+	if r.Apply == nil || r.Rollback == nil {
+		return nil, errors.New("Rollout requires both Apply and Rollback")
+	}
+
+	poll := r.Poll
+	if poll <= 0 {
+		poll = DefaultRolloutPoll
+	}
+
+	if err := c.Login(); err != nil {
+		return nil, err
+	}
+
+	before, err := c.Devices()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RolloutResult{Before: before}
+
+	if err := r.Apply(); err != nil {
+		return result, err
+	}
+
+	missing := map[string]AttachedDevice{}
+	for _, dev := range before {
+		missing[dev.MAC.String()] = dev
+	}
+
+	changes := make(chan ChangedDevice)
+	stopped := make(chan struct{})
+
+	// The watcher reports every attached device as added on the first
+	// poll, so the missing set only needs to track additions and removals.
+	// Errors are expected while the router applies the change.
+	watcher := c.Watch(poll, func(change *ChangedDevice, err error) {
+		if err != nil {
+			return
+		}
+
+		select {
+		case changes <- *change:
+		case <-stopped:
+		}
+	})
+	watcher.PollNow()
+
+	deadline := time.After(r.Window)
+
+	for waiting := true; waiting && len(missing) > 0; {
+		select {
+		case change := <-changes:
+			mac := change.Device.MAC.String()
+
+			switch change.Change {
+			case DeviceAdded:
+				delete(missing, mac)
+			case DeviceRemoved:
+				if dev, ok := beforeDevice(before, mac); ok {
+					missing[mac] = dev
+				}
+			}
+		case <-deadline:
+			waiting = false
+		}
+	}
+
+	// Unblock the listener before waiting for the watcher to stop
+	close(stopped)
+	watcher.Stop()
+
+	for _, dev := range missing {
+		result.Missing = append(result.Missing, dev)
+	}
+
+	sort.Slice(result.Missing, func(i, j int) bool {
+		return bytes.Compare(result.Missing[i].MAC, result.Missing[j].MAC) < 0
+	})
+
+	// Each device attached beforehand is a target of the rollout, failing
+	// when it did not reattach
+	outcome := &MultiError{}
+	for _, dev := range before {
+		target := Target{Router: c.Host, Action: "reattach", Device: dev.MAC}
+
+		if _, ok := missing[dev.MAC.String()]; ok {
+			outcome.Add(target, ErrNotReattached)
+		} else {
+			outcome.Add(target, nil)
+		}
+	}
+
+	if len(before) == 0 {
+		return result, nil
+	}
+
+	missingRatio := float64(len(missing)) / float64(len(before))
+	if missingRatio <= r.MaxMissing {
+		return result, outcome.ErrorOrNil()
+	}
+
+	err = r.Rollback()
+	if err != nil {
+		err = fmt.Errorf("Unable to roll back after %d devices failed to reattach: %s", len(missing), err)
+	}
+	outcome.Add(Target{Router: c.Host, Action: "roll back"}, err)
+
+	result.RolledBack = err == nil
+
+	return result, outcome.ErrorOrNil()
+}
+
+func beforeDevice(devices []AttachedDevice, mac string) (AttachedDevice, bool) {
+	for _, dev := range devices {
+		if dev.MAC.String() == mac {
+			return dev, true
+		}
+	}
+
+	return AttachedDevice{}, false
+}
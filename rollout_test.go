@@ -0,0 +1,156 @@
+package netgear_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func TestApplyWithRollback(t *testing.T) {
+	phone := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone")
+	laptop := netgeartest.Device(t, "aa:bb:cc:00:00:02", "192.168.1.3", "laptop")
+
+	tests := []struct {
+		name       string
+		after      []netgear.AttachedDevice
+		maxMissing float64
+		rolledBack bool
+		missing    bool
+	}{
+		{"all reattach", []netgear.AttachedDevice{phone, laptop}, 0, false, false},
+		{"too many missing", []netgear.AttachedDevice{laptop}, 0, true, true},
+		{"missing within limit", []netgear.AttachedDevice{laptop}, 0.5, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := netgeartest.Start(t)
+			server.SetDevices(phone, laptop)
+
+			rolledBack := false
+
+			// The zero Poll uses the default interval, the first poll is
+			// made as soon as the change is applied
+			result, err := server.Client().ApplyWithRollback(netgear.Rollout{
+				Apply: func() error {
+					server.SetDevices(tt.after...)
+					return nil
+				},
+				Rollback: func() error {
+					rolledBack = true
+					return nil
+				},
+				Window:     300 * time.Millisecond,
+				MaxMissing: tt.maxMissing,
+			})
+
+			if !tt.missing && err != nil {
+				t.Fatal(err)
+			}
+
+			// The phone is reported as the only failed target
+			multiErr := &netgear.MultiError{}
+			if tt.missing {
+				if !errors.As(err, &multiErr) || !errors.Is(err, netgear.ErrNotReattached) {
+					t.Fatalf("Expected a MultiError of devices which did not reattach, got %v", err)
+				}
+
+				failed := multiErr.FailedTargets()
+				if len(failed) != 1 || failed[0].Device.String() != phone.MAC.String() {
+					t.Errorf("Expected only the phone to fail, got %v", failed)
+				}
+			}
+
+			if result.RolledBack != tt.rolledBack || rolledBack != tt.rolledBack {
+				t.Errorf("Expected rolled back %t, got %t", tt.rolledBack, result.RolledBack)
+			}
+			if len(result.Before) != 2 {
+				t.Errorf("Expected 2 devices before the change, got %d", len(result.Before))
+			}
+		})
+	}
+}
+
+func TestApplyWithRollbackRequiresRollback(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	applied := false
+
+	_, err := server.Client().ApplyWithRollback(netgear.Rollout{
+		Apply: func() error {
+			applied = true
+			return nil
+		},
+	})
+	if err == nil {
+		t.Fatal("Expected a rollout without Rollback to be rejected")
+	}
+	if applied {
+		t.Error("The change should not be applied without a way to roll it back")
+	}
+}
+
+func TestApplyWithRollbackMissingOrder(t *testing.T) {
+	server := netgeartest.Start(t)
+
+	macs := []string{"aa:bb:cc:00:00:01", "aa:bb:cc:00:00:02", "aa:bb:cc:00:00:03", "aa:bb:cc:00:00:04"}
+
+	devices := []netgear.AttachedDevice{}
+	for _, mac := range macs {
+		devices = append(devices, netgeartest.Device(t, mac, "192.168.1.2", "device"))
+	}
+	server.SetDevices(devices...)
+
+	result, err := server.Client().ApplyWithRollback(netgear.Rollout{
+		Apply: func() error {
+			server.SetDevices()
+			return nil
+		},
+		Rollback:   func() error { return nil },
+		Window:     100 * time.Millisecond,
+		MaxMissing: 1,
+	})
+	if !errors.Is(err, netgear.ErrNotReattached) {
+		t.Fatalf("Expected the devices to fail to reattach, got %v", err)
+	}
+
+	if len(result.Missing) != len(macs) {
+		t.Fatalf("Expected %d missing devices, got %d", len(macs), len(result.Missing))
+	}
+
+	for i, dev := range result.Missing {
+		if dev.MAC.String() != macs[i] {
+			t.Errorf("Expected missing devices ordered by MAC, got %s at %d", dev.MAC, i)
+		}
+	}
+}
+
+func TestApplyWithRollbackFailed(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	result, err := server.Client().ApplyWithRollback(netgear.Rollout{
+		Apply: func() error {
+			server.SetDevices()
+			return nil
+		},
+		Rollback: func() error { return errors.New("router unreachable") },
+		Window:   100 * time.Millisecond,
+	})
+
+	multiErr := &netgear.MultiError{}
+	if !errors.As(err, &multiErr) || len(multiErr.Failed) != 2 {
+		t.Fatalf("Expected the device and the roll back to fail, got %v", err)
+	}
+
+	if action := multiErr.Failed[1].Action; action != "roll back" {
+		t.Errorf("Expected the roll back to be reported last, got %q", action)
+	}
+
+	if result.RolledBack {
+		t.Error("Expected the failed roll back not to be reported as rolled back")
+	}
+}
@@ -0,0 +1,161 @@
+package netgear
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// selfTestProbeTimeout bounds how long the self test waits to connect to the
+// router when the context has no deadline
+const selfTestProbeTimeout = 5 * time.Second
+
+// Self test steps, in the order they are run
+const (
+	SelfTestProbe    = "probe"
+	SelfTestLogin    = "login"
+	SelfTestFeatures = "features"
+	SelfTestDevices  = "devices"
+)
+
+// SelfTestStep is the result of a single self test step
+type SelfTestStep struct {
+	Name     string
+	Duration time.Duration
+
+	// Err is set when the step failed, or was skipped because the context
+	// was done
+	Err error
+
+	// Warning describes a problem which does not prevent the client from
+	// working, such as firmware without a feature list
+	Warning string
+
+	// Skipped is set when an earlier step failed
+	Skipped bool
+}
+
+// SelfTestReport is the result of SelfTest
+type SelfTestReport struct {
+	Host  string
+	Port  int
+	Steps []SelfTestStep
+}
+
+// OK reports if every step passed, possibly with warnings
+func (r *SelfTestReport) OK() bool {
+	return r.Err() == nil
+}
+
+// Err returns the error of the first failed step, nil when all steps passed
+func (r *SelfTestReport) Err() error {
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			return fmt.Errorf("Self test of %s:%d failed at %s: %w", r.Host, r.Port, step.Name, step.Err)
+		}
+	}
+
+	return nil
+}
+
+// Step looks up a step of the report by name
+func (r *SelfTestReport) Step(name string) *SelfTestStep {
+	for i := range r.Steps {
+		if r.Steps[i].Name == name {
+			return &r.Steps[i]
+		}
+	}
+
+	return nil
+}
+
+// selfTestSteps are run in order by SelfTest. Each returns a warning, or an
+// error when the step failed.
+var selfTestSteps = []struct {
+	name string
+	run  func(c *Client, ctx context.Context) (string, error)
+}{
+	{SelfTestProbe, (*Client).selfTestProbe},
+	{SelfTestLogin, func(c *Client, ctx context.Context) (string, error) { return "", c.login(ctx) }},
+	{SelfTestFeatures, (*Client).selfTestFeatures},
+	{SelfTestDevices, (*Client).selfTestDevices},
+}
+
+// SelfTest checks that the client is configured correctly by connecting to
+// the router, logging in, reading the feature list, and fetching the attached
+// devices once. None of the steps change the router configuration. Daemons
+// can run this at startup to report misconfiguration before the first poll.
+//
+// Every request is made with ctx, so a deadline bounds the whole self test.
+// Steps following a failed step are skipped. The returned report is always
+// complete, use its Err method to determine if the self test passed.
+func (c *Client) SelfTest(ctx context.Context) *SelfTestReport {
+	report := &SelfTestReport{Host: c.Host, Port: c.Port}
+
+	var failed bool
+
+	for _, step := range selfTestSteps {
+		result := SelfTestStep{Name: step.name}
+
+		switch {
+		case failed:
+			result.Skipped = true
+		case ctx.Err() != nil:
+			result.Err = ctx.Err()
+			failed = true
+		default:
+			start := time.Now()
+			result.Warning, result.Err = step.run(c, ctx)
+			result.Duration = time.Since(start)
+			failed = result.Err != nil
+		}
+
+		report.Steps = append(report.Steps, result)
+	}
+
+	return report
+}
+
+func (c *Client) selfTestProbe(ctx context.Context) (string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, selfTestProbeTimeout)
+		defer cancel()
+	}
+
+	conn, err := c.dial.dialContext(ctx, "tcp", net.JoinHostPort(c.Host, strconv.Itoa(c.Port)))
+	if err != nil {
+		return "", err
+	}
+	conn.Close()
+
+	return "", nil
+}
+
+func (c *Client) selfTestFeatures(ctx context.Context) (string, error) {
+	features, err := c.features(ctx)
+	if err != nil {
+		return fmt.Sprintf("No feature list published, older firmware may not support newer APIs (%s)", err), nil
+	}
+
+	if len(features) == 0 {
+		return "Feature list is empty", nil
+	}
+
+	return "", nil
+}
+
+func (c *Client) selfTestDevices(ctx context.Context) (string, error) {
+	list, err := c.deviceList(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if !list.Complete() {
+		return fmt.Sprintf("Router reported %d devices but %d were listed, the response may be truncated", list.Reported, list.Listed()), nil
+	}
+
+	return "", nil
+}
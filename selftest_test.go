@@ -0,0 +1,41 @@
+package netgear_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func TestSelfTest(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	report := server.Client().SelfTest(context.Background())
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelfTestDeadline(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	report := server.Client().SelfTest(ctx)
+
+	// The probe only connects, the deadline must cut the login request short
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Self test took %s, expected the deadline to end it", elapsed)
+	}
+
+	if step := report.Step(netgear.SelfTestLogin); step == nil || !errors.Is(step.Err, context.DeadlineExceeded) {
+		t.Errorf("Expected login to exceed the deadline, got %+v", step)
+	}
+}
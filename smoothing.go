@@ -0,0 +1,40 @@
+package netgear
+
+import "math"
+
+// WithSignalSmoothing applies an exponentially weighted moving average to the
+// signal strength of attached devices, so thresholds on the signal don't flap
+// as it jumps around between polls. Alpha is the weight given to the newest
+// reading, between 0 and 1; lower values smooth more.
+//
+// The Signal of devices reported by the watcher is the smoothed value. The
+// average is reset when a device leaves the network.
+func WithSignalSmoothing(alpha float64) WatchOption {
+	return func(w *Watcher) {
+		w.signalAlpha = alpha
+	}
+}
+
+// smoothSignals replaces the signal of each device with its moving average.
+// Must be called with the lock held.
+func (w *Watcher) smoothSignals(devices []AttachedDevice) {
+	if w.signalAlpha <= 0 {
+		return
+	}
+
+	signals := make(map[string]float64, len(devices))
+
+	for i, dev := range devices {
+		mac := dev.MAC.String()
+		signal := float64(dev.Signal)
+
+		if prev, ok := w.signals[mac]; ok {
+			signal = w.signalAlpha*signal + (1-w.signalAlpha)*prev
+		}
+
+		signals[mac] = signal
+		devices[i].Signal = int(math.Round(signal))
+	}
+
+	w.signals = signals
+}
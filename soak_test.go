@@ -0,0 +1,356 @@
+//go:build hardware
+
+package netgear_test
+
+import (
+	"errors"
+	"flag"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+var (
+	soakHost     = flag.String("soak.host", "192.168.1.1", "Your netgear router address")
+	soakPort     = flag.Int("soak.port", 5000, "Your netgear router SOAP port")
+	soakUsername = flag.String("soak.username", "admin", "Your netgear router username")
+	soakPassword = flag.String("soak.password", "", "Your netgear router password")
+
+	soakDuration = flag.Duration("soak.duration", 4*time.Hour, "How long to run the soak for")
+	soakPoll     = flag.Duration("soak.poll", 10*time.Second, "Watcher poll interval")
+	soakActions  = flag.Duration("soak.actions", time.Minute, "Interval between runs of the read only actions")
+	soakReport   = flag.Duration("soak.report", 10*time.Minute, "Interval between progress reports")
+
+	soakMaxErrorRate   = flag.Float64("soak.max-error-rate", 0.02, "Fraction of failed calls tolerated")
+	soakMaxErrorGrowth = flag.Float64("soak.max-error-growth", 0.01, "Increase in the fraction of failed calls between the first and second half of the run tolerated")
+	soakMaxMissedPolls = flag.Float64("soak.max-missed-polls", 0.05, "Fraction of watcher polls tolerated to be missed")
+	soakMaxHeapGrowth  = flag.Int("soak.max-heap-growth", 32, "Heap growth tolerated, in megabytes")
+	soakMaxOutage      = flag.Duration("soak.max-outage", 2*time.Minute, "Longest period of consecutive watcher failures tolerated")
+)
+
+// soakWarmup is how long to wait before taking the baseline memory
+// measurement, so caches and connection pools have been populated
+const soakWarmup = 2 * time.Minute
+
+// soakActionCalls are the read only calls made each actions interval
+var soakActionCalls = map[string]func(c *netgear.Client) error{
+	"Info":                func(c *netgear.Client) error { _, err := c.Info(); return err },
+	"WAN":                 func(c *netgear.Client) error { _, err := c.WAN(); return err },
+	"WirelessInfo":        func(c *netgear.Client) error { _, err := c.WirelessInfo(netgear.Band2G); return err },
+	"Features":            func(c *netgear.Client) error { _, err := c.Features(); return err },
+	"TrafficMeterOptions": func(c *netgear.Client) error { _, err := c.TrafficMeterOptions(); return err },
+	"ClockSkew":           func(c *netgear.Client) error { _, err := c.ClockSkew(); return err },
+}
+
+// soakCounter tracks the outcome of calls to a single action, separately for
+// each half of the run
+type soakCounter struct {
+	calls    [2]int
+	failures [2]int
+	lastErr  error
+
+	// unsupported actions are not implemented by the firmware, and are no
+	// longer called
+	unsupported bool
+}
+
+func (c *soakCounter) rate(halves ...int) float64 {
+	calls, failures := 0, 0
+	for _, h := range halves {
+		calls += c.calls[h]
+		failures += c.failures[h]
+	}
+
+	if calls == 0 {
+		return 0
+	}
+
+	return float64(failures) / float64(calls)
+}
+
+// soak collects the results of the soak run
+type soak struct {
+	mu       sync.Mutex
+	started  time.Time
+	counters map[string]*soakCounter
+
+	// Session stability
+	sessionErrors int
+	polls         int
+	outageStart   time.Time
+	longestOutage time.Duration
+	recoveries    int
+
+	baselineHeap       uint64
+	baselineGoroutines int
+	peakHeap           uint64
+}
+
+// half is the half of the run a call falls in
+func (s *soak) half() int {
+	if time.Since(s.started) < *soakDuration/2 {
+		return 0
+	}
+
+	return 1
+}
+
+func (s *soak) record(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[name]
+	if !ok {
+		c = &soakCounter{}
+		s.counters[name] = c
+	}
+
+	respErr := &netgear.ResponseError{}
+	isResponseErr := errors.As(err, &respErr)
+
+	if isResponseErr && respErr.Code == 501 && c.calls[0] == 0 {
+		c.unsupported = true
+		return
+	}
+
+	half := s.half()
+
+	c.calls[half]++
+	if err == nil {
+		return
+	}
+
+	c.failures[half]++
+	c.lastErr = err
+
+	// Rejected credentials mid run indicate the session was dropped and not
+	// reestablished
+	if isResponseErr && respErr.Code == 401 {
+		s.sessionErrors++
+	}
+}
+
+func (s *soak) supported(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[name]
+
+	return !ok || !c.unsupported
+}
+
+// afterPoll counts polls and tracks watcher outages, a run of consecutive
+// failed polls
+func (s *soak) afterPoll(stats netgear.PollStats) {
+	s.record("Watcher", stats.Err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.polls++
+
+	switch {
+	case stats.Err != nil && s.outageStart.IsZero():
+		s.outageStart = stats.Started
+	case stats.Err == nil && !s.outageStart.IsZero():
+		if outage := stats.Started.Sub(s.outageStart); outage > s.longestOutage {
+			s.longestOutage = outage
+		}
+		s.outageStart = time.Time{}
+		s.recoveries++
+	}
+}
+
+func (s *soak) runActions(client *netgear.Client) {
+	if err := client.Login(); err != nil {
+		s.record("Login", err)
+		return
+	}
+	s.record("Login", nil)
+
+	for name, action := range soakActionCalls {
+		if s.supported(name) {
+			s.record(name, action(client))
+		}
+	}
+}
+
+func (s *soak) measureMemory(baseline bool) {
+	runtime.GC()
+
+	mem := runtime.MemStats{}
+	runtime.ReadMemStats(&mem)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if baseline {
+		s.baselineHeap = mem.HeapAlloc
+		s.baselineGoroutines = runtime.NumGoroutine()
+	}
+
+	if mem.HeapAlloc > s.peakHeap {
+		s.peakHeap = mem.HeapAlloc
+	}
+}
+
+func (s *soak) names() []string {
+	names := make([]string, 0, len(s.counters))
+	for name := range s.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (s *soak) report(t *testing.T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t.Logf("After %s", time.Since(s.started).Round(time.Second))
+
+	for _, name := range s.names() {
+		c := s.counters[name]
+		if c.unsupported {
+			t.Logf("  %-20s unsupported by the firmware", name)
+			continue
+		}
+
+		t.Logf("  %-20s %6d calls %5d failed (%.2f%%), last error: %v",
+			name, c.calls[0]+c.calls[1], c.failures[0]+c.failures[1], c.rate(0, 1)*100, c.lastErr)
+	}
+
+	t.Logf("  %-20s %d polls, %d session errors, %d recoveries, longest outage %s", "Session", s.polls, s.sessionErrors, s.recoveries, s.longestOutage)
+	t.Logf("  %-20s peak heap %.1f MB, %d goroutines", "Memory", soakMegabytes(s.peakHeap), runtime.NumGoroutine())
+}
+
+// check fails the test for each threshold the run exceeded
+func (s *soak) check(t *testing.T, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, name := range s.names() {
+		c := s.counters[name]
+
+		if rate := c.rate(0, 1); rate > *soakMaxErrorRate {
+			t.Errorf("%s failed %.2f%% of calls", name, rate*100)
+		}
+
+		if growth := c.rate(1) - c.rate(0); growth > *soakMaxErrorGrowth {
+			t.Errorf("%s failures grew from %.2f%% to %.2f%% of calls over the run", name, c.rate(0)*100, c.rate(1)*100)
+		}
+	}
+
+	if s.sessionErrors > 0 {
+		t.Errorf("Session was rejected %d times, it was dropped without being reestablished", s.sessionErrors)
+	}
+
+	if expected := int(elapsed / *soakPoll); float64(expected-s.polls) > float64(expected)**soakMaxMissedPolls {
+		t.Errorf("Watcher polled %d times, expected %d", s.polls, expected)
+	}
+
+	outage := s.longestOutage
+	if !s.outageStart.IsZero() && time.Since(s.outageStart) > outage {
+		outage = time.Since(s.outageStart)
+	}
+	if outage > *soakMaxOutage {
+		t.Errorf("Watcher failed continuously for %s", outage.Round(time.Second))
+	}
+
+	if s.baselineHeap > 0 && s.peakHeap > s.baselineHeap {
+		if growth := soakMegabytes(s.peakHeap - s.baselineHeap); growth > float64(*soakMaxHeapGrowth) {
+			t.Errorf("Heap grew by %.1f MB", growth)
+		}
+	}
+
+	if s.baselineGoroutines > 0 && runtime.NumGoroutine() > s.baselineGoroutines {
+		t.Errorf("Goroutines grew from %d to %d after the client was closed", s.baselineGoroutines, runtime.NumGoroutine())
+	}
+}
+
+func soakMegabytes(bytes uint64) float64 {
+	return float64(bytes) / 1024 / 1024
+}
+
+func soakClient() *netgear.Client {
+	client := netgear.NewClient(*soakHost, *soakUsername, *soakPassword)
+	client.Port = *soakPort
+
+	return client
+}
+
+// TestSoak runs the watcher and a set of read only actions against a real
+// router for an extended period. It is built only with the hardware tag:
+//
+//	go test -tags=hardware -run TestSoak -timeout 0 -v . -soak.password ... -soak.duration 6h
+//
+// The soak fails on leaked sessions, error rates growing over the run, polls
+// the watcher missed, and memory or goroutine growth, so regressions in the
+// reconnection logic can be caught before a release.
+func TestSoak(t *testing.T) {
+	if *soakPassword == "" {
+		t.Skip("Set -soak.password to soak a router")
+	}
+
+	client := soakClient()
+
+	s := &soak{started: time.Now(), counters: map[string]*soakCounter{}}
+
+	t.Logf("Soaking %s:%d for %s", *soakHost, *soakPort, *soakDuration)
+
+	watcher := client.Watch(*soakPoll, func(*netgear.ChangedDevice, error) {},
+		netgear.WithDetailedEvery(6),
+		netgear.WithAfterPoll(s.afterPoll),
+	)
+
+	actionTicker := time.NewTicker(*soakActions)
+	reportTicker := time.NewTicker(*soakReport)
+	baseline := time.After(soakWarmup)
+	done := time.After(*soakDuration)
+
+	s.runActions(client)
+
+	for running := true; running; {
+		select {
+		case <-actionTicker.C:
+			s.runActions(client)
+			s.measureMemory(false)
+		case <-baseline:
+			s.measureMemory(true)
+		case <-reportTicker.C:
+			s.report(t)
+		case <-done:
+			running = false
+		}
+	}
+
+	elapsed := time.Since(s.started)
+
+	watcher.Stop()
+	actionTicker.Stop()
+	reportTicker.Stop()
+
+	if err := client.Close(); err != nil {
+		t.Errorf("Unable to close the session, %s", err)
+	}
+
+	// A session left open by the closed client blocks new logins on
+	// firmware allowing a single session
+	fresh := soakClient()
+	if err := fresh.Login(); err != nil {
+		t.Errorf("Unable to log in after closing the client, the session may have leaked: %s", err)
+	}
+	fresh.Close()
+
+	// Connections of the closed clients are given a moment to wind down
+	time.Sleep(time.Second)
+
+	s.measureMemory(false)
+	s.report(t)
+	s.check(t, elapsed)
+}
@@ -0,0 +1,387 @@
+package netgear
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+const soapLogin = `
+<Authenticate>
+  <NewUsername>{{.username}}</NewUsername>
+  <NewPassword>{{.password}}</NewPassword>
+</Authenticate>`
+
+const soapLogout = `
+<M1:SOAPLogout xmlns:M1="{{.urn}}">
+</M1:SOAPLogout>`
+
+const soapAttachedDev = `
+<M1:GetAttachDevice xmlns:M1="{{.urn}}">
+</M1:GetAttachDevice>`
+
+const soapAttachedDev2 = `
+<M1:GetAttachDevice2 xmlns:M1="{{.urn}}">
+</M1:GetAttachDevice2>`
+
+// soapGeneric is used for actions without a dedicated template. The action
+// parameters are pre-encoded as XML elements.
+const soapGeneric = `
+<M1:{{.method}} xmlns:M1="{{.urn}}">{{.elements}}
+</M1:{{.method}}>`
+
+// soapService is the name of a netgear SOAP service, without the URN prefix
+// or version
+type soapService string
+
+// soapAction is formatted as <service>#<method>. The versioned service URN is
+// determined when the action is called.
+type soapAction string
+
+const (
+	loginAction        soapAction = soapconst.ParentalControlAuthenticate
+	logoutAction       soapAction = soapconst.DeviceConfigSOAPLogout
+	attachedDevAction  soapAction = soapconst.DeviceInfoGetAttachDevice
+	attachedDev2Action soapAction = soapconst.DeviceInfoGetAttachDevice2
+)
+
+func (a soapAction) service() soapService {
+	return soapService(strings.SplitN(string(a), "#", 2)[0])
+}
+
+func (a soapAction) method() string {
+	return strings.SplitN(string(a), "#", 2)[1]
+}
+
+var (
+	loginTemplate, _        = template.New("login").Parse(soapLogin)
+	logoutTemplate, _       = template.New("logout").Parse(soapLogout)
+	attachedDevTemplate, _  = template.New("attachedDev").Parse(soapAttachedDev)
+	attachedDev2Template, _ = template.New("attachedDev2").Parse(soapAttachedDev2)
+	genericTemplate, _      = template.New("generic").Parse(soapGeneric)
+)
+
+// Map actions to the body templates they should render. The body is wrapped
+// in the clients Envelope.
+var soapTemplates = map[soapAction]*template.Template{
+	loginAction:        loginTemplate,
+	logoutAction:       logoutTemplate,
+	attachedDevAction:  attachedDevTemplate,
+	attachedDev2Action: attachedDev2Template,
+}
+
+type soapResponseCode struct {
+	ResponseCode int `xml:"ResponseCode"`
+}
+
+// codeNotSupported is the response code used by the router when the
+// requested service version or method is not implemented
+const codeNotSupported = 501
+
+// versions lists the versions of a service to try when calling an action.
+// Services published under multiple versions with differing payloads are
+// tried newest first until the router accepts one. Once a version has been
+// negotiated it is the only version tried.
+func (c *Client) versions(service soapService) []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version, ok := c.negotiated[service]; ok {
+		return []int{version}
+	}
+
+	return soapconst.Versions(string(service))
+}
+
+func (c *Client) setVersion(service soapService, version int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.negotiated == nil {
+		c.negotiated = map[soapService]int{}
+	}
+
+	c.negotiated[service] = version
+}
+
+func (c *Client) soap(action soapAction, params map[string]string) (*http.Response, error) {
+	return c.soapContext(context.Background(), action, params)
+}
+
+// soapContext makes a call which is cancelled when either ctx is done or the
+// client is closed
+func (c *Client) soapContext(ctx context.Context, action soapAction, params map[string]string) (*http.Response, error) {
+	lifecycleCtx, err := c.lifecycle.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	callCtx, end := lifecycleCtx, c.lifecycle.end
+
+	// Contexts which are never done, such as context.Background, need not
+	// be watched
+	if ctx.Done() != nil {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithCancel(ctx)
+
+		go func() {
+			select {
+			case <-lifecycleCtx.Done():
+				cancel()
+			case <-callCtx.Done():
+			}
+		}()
+
+		end = func() {
+			cancel()
+			c.lifecycle.end()
+		}
+	}
+
+	resp, err := c.soapNegotiate(callCtx, action, params)
+	if err == nil {
+		err = c.parse(action, resp)
+	}
+	if err != nil {
+		end()
+		return nil, err
+	}
+
+	// The call remains in-flight until the caller has read the response
+	resp.Body = &trackedBody{ReadCloser: resp.Body, end: end}
+
+	return resp, nil
+}
+
+func (c *Client) soapNegotiate(ctx context.Context, action soapAction, params map[string]string) (*http.Response, error) {
+	service := action.service()
+	versions := c.versions(service)
+
+	for i, version := range versions {
+		resp, err := c.soapVersion(ctx, action, version, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if parser, ok := versionParsers[service][version]; ok {
+			if err := rewriteBody(parser, action, resp); err != nil {
+				return nil, err
+			}
+		}
+
+		if len(versions) == 1 {
+			return resp, nil
+		}
+
+		notSupported, parsed := isNotSupported(resp)
+
+		// Fall back to the next oldest version when the router does not
+		// implement this one.
+		if notSupported && i < len(versions)-1 {
+			resp.Body.Close()
+			continue
+		}
+
+		// Only cache the version once the router has answered with a
+		// response code, or rejected every newer version. A dropped or
+		// truncated response says nothing about the versions implemented.
+		if parsed || i > 0 {
+			c.setVersion(service, version)
+		}
+
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("No versions of %s are available", service)
+}
+
+func (c *Client) soapVersion(ctx context.Context, action soapAction, version int, params map[string]string) (*http.Response, error) {
+	urn := soapconst.URN(string(action.service()), version)
+
+	templateParams := map[string]string{"urn": urn, "method": action.method()}
+	for k, v := range params {
+		templateParams[k] = v
+	}
+
+	bodyTemplate, ok := soapTemplates[action]
+	if !ok {
+		bodyTemplate = genericTemplate
+	}
+
+	templateBody, err := c.envelopeFor(action).render(bodyTemplate, templateParams)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s://%s:%d%s", c.dial.scheme(), c.Host, c.Port, soapconst.Path)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, templateBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("SOAPAction", urn+"#"+action.method())
+
+	return c.getHTTPClient().Do(req)
+}
+
+// isNotSupported checks if the router rejected the service version, either
+// at the HTTP level or through the SOAP response code, and if the response
+// could be parsed at all. The response body is buffered so that it may still
+// be decoded by the caller.
+func isNotSupported(resp *http.Response) (notSupported, parsed bool) {
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented {
+		return true, true
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false, false
+	}
+
+	type soapEnvelope struct {
+		Body soapResponseCode `xml:"Body"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return false, false
+	}
+
+	return envelope.Body.ResponseCode == codeNotSupported, true
+}
+
+// versionParsers normalize the responses of newer service versions into the
+// layout of version 1, which is what the client decodes
+var versionParsers = map[soapService]map[int]ResponseParser{
+	soapconst.DeviceInfo:        {2: hoistResponseCode},
+	soapconst.WLANConfiguration: {2: hoistResponseCode},
+}
+
+var (
+	responseCodePattern = regexp.MustCompile(`\s*<ResponseCode>\s*(\d+)\s*</ResponseCode>`)
+	bodyEndPattern      = regexp.MustCompile(`</([\w-]+:)?Body>`)
+)
+
+// hoistResponseCode moves the response code out of the action response
+// element, where version 2 of a service reports it, to sit alongside it as
+// it does in version 1
+func hoistResponseCode(action string, body []byte) ([]byte, error) {
+	type soapEnvelope struct {
+		Body soapResponseCode `xml:"Body"`
+	}
+
+	// Responses already in the version 1 layout are left as is
+	envelope := soapEnvelope{Body: soapResponseCode{ResponseCode: -1}}
+	xml.Unmarshal(body, &envelope)
+	if envelope.Body.ResponseCode != -1 {
+		return body, nil
+	}
+
+	code := responseCodePattern.Find(body)
+	end := bodyEndPattern.FindIndex(body)
+	if code == nil || end == nil {
+		return body, nil
+	}
+
+	hoisted := bytes.Replace(body, code, nil, 1)
+	end = bodyEndPattern.FindIndex(hoisted)
+
+	result := make([]byte, 0, len(body))
+	result = append(result, hoisted[:end[0]]...)
+	result = append(result, bytes.TrimSpace(code)...)
+	result = append(result, '\n')
+	result = append(result, hoisted[end[0]:]...)
+
+	return result, nil
+}
+
+// SOAPParam is a named parameter passed to a SOAP action
+type SOAPParam struct {
+	Name  string
+	Value string
+}
+
+func encodeParams(params []SOAPParam) string {
+	b := &strings.Builder{}
+
+	for _, p := range params {
+		fmt.Fprintf(b, "\n  <%s>", p.Name)
+		xml.EscapeText(b, []byte(p.Value))
+		fmt.Fprintf(b, "</%s>", p.Name)
+	}
+
+	return b.String()
+}
+
+// soapRaw calls an action using the generic template, returning the raw
+// response body
+func (c *Client) soapRaw(ctx context.Context, action soapAction, params []SOAPParam) ([]byte, error) {
+	resp, err := c.soapContext(ctx, action, map[string]string{
+		"sessionID": c.SessionID,
+		"elements":  encodeParams(params),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// call calls an action using the generic template. The response envelope is
+// decoded into out, which should use paths starting from the envelope, such
+// as `xml:"Body>GetInfoResponse>ModelName"`. Non-zero response codes are
+// returned as a ResponseError with the given op.
+func (c *Client) call(op string, action soapAction, params []SOAPParam, out interface{}) error {
+	return c.callContext(context.Background(), op, action, params, out)
+}
+
+// callContext is call, cancelled when ctx is done
+func (c *Client) callContext(ctx context.Context, op string, action soapAction, params []SOAPParam, out interface{}) error {
+	body, err := c.soapRaw(ctx, action, params)
+	if err != nil {
+		return err
+	}
+
+	type soapEnvelope struct {
+		Body soapResponseCode `xml:"Body"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return err
+	}
+
+	respCode := envelope.Body.ResponseCode
+	if respCode != 0 {
+		return &ResponseError{Op: op, Code: respCode, Action: string(action)}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return xml.Unmarshal(body, out)
+}
+
+// RawSOAP calls an arbitrary action, returning the raw response envelope.
+// Actions are formatted as <service>#<method>, for example
+// soapconst.DeviceInfoGetInfo, the service version is negotiated as usual.
+// The response code is not checked.
+func (c *Client) RawSOAP(action string, params ...SOAPParam) ([]byte, error) {
+	if !strings.Contains(action, "#") {
+		return nil, fmt.Errorf("Action %q is not formatted as <service>#<method>", action)
+	}
+
+	return c.soapRaw(context.Background(), soapAction(action), params)
+}
@@ -0,0 +1,90 @@
+package netgear_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+func TestNegotiateNewerVersion(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetServiceVersion(soapconst.DeviceInfo, 2)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	client := server.Client()
+
+	// The response code is reported in the version 2 layout
+	_, err := client.Devices()
+
+	respErr := &netgear.ResponseError{}
+	if !errors.As(err, &respErr) || respErr.Code != netgeartest.CodeUnauthorized {
+		t.Fatalf("Expected unauthorized response error, got %v", err)
+	}
+
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err := client.Devices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("Expected 1 device, got %d", len(devices))
+	}
+
+	info, err := client.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Model != "R7000" {
+		t.Errorf("Expected model R7000, got %q", info.Model)
+	}
+
+	// Once negotiated the version is the only one tried
+	server.SetServiceVersion(soapconst.DeviceInfo, 1)
+
+	_, err = client.Devices()
+	if !errors.As(err, &respErr) || respErr.Code != netgeartest.CodeNotSupported {
+		t.Fatalf("Expected the negotiated version to be used, got %v", err)
+	}
+}
+
+func TestNegotiateFallback(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Devices(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNegotiateTruncated(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A truncated response to the newest version does not settle the
+	// negotiation
+	server.TruncateResponses(1)
+
+	if _, err := client.Devices(); err == nil {
+		t.Fatal("Expected a truncated response to fail")
+	}
+
+	if _, err := client.Devices(); err != nil {
+		t.Fatalf("Expected the version to be negotiated again, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Package soapconst lists the SOAP services and actions of netgear routers
+// used by the netgear client, along with the endpoint they are served from.
+// Use these when calling actions the client does not wrap with RawSOAP, so
+// the names stay in sync with the client.
+package soapconst
+
+import "fmt"
+
+// DefaultPort is the port routers serve SOAP on, some firmware uses 80
+const DefaultPort = 5000
+
+// Path is the path of the SOAP endpoint on the router
+const Path = "/soap/server_sa"
+
+// URNPrefix prefixes the name and version of a service to form its URN
+const URNPrefix = "urn:NETGEAR-ROUTER:service:"
+
+// Services
+const (
+	DeviceConfig      = "DeviceConfig"
+	DeviceInfo        = "DeviceInfo"
+	ParentalControl   = "ParentalControl"
+	WANIPConnection   = "WANIPConnection"
+	WLANConfiguration = "WLANConfiguration"
+)
+
+// Actions are formatted as <service>#<method>
+const (
+	DeviceConfigSOAPLogout                       = DeviceConfig + "#SOAPLogout"
+	DeviceConfigConfigurationStarted             = DeviceConfig + "#ConfigurationStarted"
+	DeviceConfigConfigurationFinished            = DeviceConfig + "#ConfigurationFinished"
+	DeviceConfigSetBlockDeviceByMAC              = DeviceConfig + "#SetBlockDeviceByMAC"
+	DeviceConfigSetBlockDeviceEnable             = DeviceConfig + "#SetBlockDeviceEnable"
+	DeviceConfigGetBlockDeviceEnableStatus       = DeviceConfig + "#GetBlockDeviceEnableStatus"
+	DeviceConfigGetTrafficMeterStatistics        = DeviceConfig + "#GetTrafficMeterStatistics"
+	DeviceConfigGetTrafficMeterOptions           = DeviceConfig + "#GetTrafficMeterOptions"
+	DeviceInfoGetInfo                            = DeviceInfo + "#GetInfo"
+	DeviceInfoGetAttachDevice                    = DeviceInfo + "#GetAttachDevice"
+	DeviceInfoGetAttachDevice2                   = DeviceInfo + "#GetAttachDevice2"
+	DeviceInfoGetSupportFeatureListXML           = DeviceInfo + "#GetSupportFeatureListXML"
+	ParentalControlAuthenticate                  = ParentalControl + "#Authenticate"
+	WANIPConnectionGetInfo                       = WANIPConnection + "#GetInfo"
+	WLANConfigurationGetInfo                     = WLANConfiguration + "#GetInfo"
+	WLANConfigurationGet5GInfo                   = WLANConfiguration + "#Get5GInfo"
+	WLANConfigurationGetGuestAccessEnabled       = WLANConfiguration + "#GetGuestAccessEnabled"
+	WLANConfigurationGet5GGuestAccessEnabled     = WLANConfiguration + "#Get5GGuestAccessEnabled"
+	WLANConfigurationGetGuestAccessNetworkInfo   = WLANConfiguration + "#GetGuestAccessNetworkInfo"
+	WLANConfigurationGet5GGuestAccessNetworkInfo = WLANConfiguration + "#Get5GGuestAccessNetworkInfo"
+	WLANConfigurationSetGuestAccessEnabled       = WLANConfiguration + "#SetGuestAccessEnabled"
+	WLANConfigurationSetGuestAccessEnabled2      = WLANConfiguration + "#SetGuestAccessEnabled2"
+	WLANConfigurationSet5GGuestAccessEnabled     = WLANConfiguration + "#Set5GGuestAccessEnabled"
+	WLANConfigurationSet5GGuestAccessEnabled2    = WLANConfiguration + "#Set5GGuestAccessEnabled2"
+)
+
+// Some services are published under multiple versions with differing
+// payloads
+var versions = map[string][]int{
+	DeviceInfo:        {2, 1},
+	WLANConfiguration: {2, 1},
+}
+
+// Versions lists the versions of a service, newest first. Services
+// published under a single version only list version 1.
+func Versions(service string) []int {
+	v, ok := versions[service]
+	if !ok {
+		return []int{1}
+	}
+
+	return append([]int(nil), v...)
+}
+
+// URN constructs the full URN of a version of a service
+func URN(service string, version int) string {
+	return fmt.Sprintf("%s%s:%d", URNPrefix, service, version)
+}
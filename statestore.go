@@ -0,0 +1,172 @@
+package netgear
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// WatcherState is the state a Watcher needs for another instance to take
+// over polling without reporting already known devices again
+type WatcherState struct {
+	Devices  []AttachedDevice
+	Detailed []AttachedDevice
+	Signals  map[string]float64
+	Meta     map[string]DeviceMeta
+	Presence map[string]PersonState
+	Polls    int
+	SavedAt  time.Time
+}
+
+// StateStore persists watcher state externally, so a standby instance in a
+// high availability deployment can take over polling. Load returns nil when
+// no state has been saved.
+type StateStore interface {
+	Load() (*WatcherState, error)
+	Save(state *WatcherState) error
+}
+
+// WithStateStore restores the watcher state from the store before the first
+// poll, and saves it after every successful poll. The state is restored again
+// on the first poll after cycles skipped by WithBeforePoll, once this
+// instance takes over. State is saved before the
+// changes of a poll are reported, so a takeover may miss changes rather than
+// duplicate them. Errors using the store are reported to the listeners,
+// polling continues regardless.
+func WithStateStore(store StateStore) WatchOption {
+	return func(w *Watcher) {
+		w.store = store
+	}
+}
+
+// WithPresence saves and restores the presence of the people tracked by p
+// along with the watcher state, see WithStateStore. Without it a standby
+// taking over reports no changes for already attached devices, leaving p
+// empty. The presence must still be subscribed to the watcher.
+func WithPresence(p *Presence) WatchOption {
+	return func(w *Watcher) {
+		w.presence = p
+	}
+}
+
+// State captures the current state of the watcher
+func (w *Watcher) State() *WatcherState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.state()
+}
+
+// state captures the watcher state. Must be called with the lock held.
+func (w *Watcher) state() *WatcherState {
+	state := &WatcherState{
+		Devices:  append([]AttachedDevice{}, w.devices...),
+		Detailed: make([]AttachedDevice, 0, len(w.detailed)),
+		Signals:  make(map[string]float64, len(w.signals)),
+		Meta:     make(map[string]DeviceMeta, len(w.meta)),
+		Polls:    w.polls,
+		SavedAt:  time.Now(),
+	}
+
+	for _, dev := range w.detailed {
+		state.Detailed = append(state.Detailed, dev)
+	}
+
+	for mac, signal := range w.signals {
+		state.Signals[mac] = signal
+	}
+
+	for mac, meta := range w.meta {
+		state.Meta[mac] = meta
+	}
+
+	if w.presence != nil {
+		state.Presence = w.presence.snapshot()
+	}
+
+	return state
+}
+
+// restore replaces the watcher state. Must be called with the lock held.
+func (w *Watcher) restore(state *WatcherState) {
+	w.devices = state.Devices
+	w.index = nil
+	w.polls = state.Polls
+
+	w.detailed = make(map[string]AttachedDevice, len(state.Detailed))
+	for _, dev := range state.Detailed {
+		w.detailed[dev.MAC.String()] = dev
+	}
+
+	if state.Signals != nil {
+		w.signals = state.Signals
+	}
+
+	for mac, meta := range state.Meta {
+		w.meta[mac] = meta
+	}
+
+	if w.presence != nil && state.Presence != nil {
+		w.presence.restore(state.Presence)
+	}
+}
+
+// loadState restores the watcher state from the store, if any has been saved
+func (w *Watcher) loadState() {
+	state, err := w.store.Load()
+	if err == nil && state == nil {
+		return
+	}
+
+	w.dispatchMu.Lock()
+	defer w.dispatchMu.Unlock()
+
+	if err != nil {
+		w.dispatch(nil, err)
+		return
+	}
+
+	w.mu.Lock()
+	w.restore(state)
+	w.mu.Unlock()
+}
+
+// RedisClient is the subset of a Redis client used by RedisStateStore. Most
+// Redis libraries need a small adapter to satisfy it. Get returns nil when
+// the key does not exist.
+type RedisClient interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// RedisStateStore is a StateStore saving the watcher state as JSON under a
+// Redis key
+type RedisStateStore struct {
+	Client RedisClient
+	Key    string
+}
+
+// Load implements StateStore
+func (s *RedisStateStore) Load() (*WatcherState, error) {
+	contents, err := s.Client.Get(context.Background(), s.Key)
+	if err != nil || contents == nil {
+		return nil, err
+	}
+
+	state := &WatcherState{}
+	if err := json.Unmarshal(contents, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Save implements StateStore
+func (s *RedisStateStore) Save(state *WatcherState) error {
+	contents, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return s.Client.Set(context.Background(), s.Key, contents)
+}
@@ -0,0 +1,108 @@
+package netgear_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+// memoryRedis is a RedisClient holding keys in memory
+type memoryRedis struct {
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+func (r *memoryRedis) Get(ctx context.Context, key string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.keys[key], nil
+}
+
+func (r *memoryRedis) Set(ctx context.Context, key string, value []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[key] = value
+	return nil
+}
+
+func TestStateStorePresence(t *testing.T) {
+	server := netgeartest.Start(t)
+	phone := netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone")
+	server.SetDevices(phone)
+
+	store := &netgear.RedisStateStore{Client: &memoryRedis{keys: map[string][]byte{}}, Key: "watcher"}
+	alice := netgear.Person{Name: "alice", Devices: []net.HardwareAddr{phone.MAC}}
+
+	primary := netgear.NewPresence(alice)
+	watcher, recorder := watch(t, server.Client(), netgear.WithStateStore(store), netgear.WithPresence(primary))
+	watcher.Subscribe(primary.Listener(), true)
+
+	// State is saved before the changes of a poll are reported, the second
+	// poll saves alice arriving
+	watcher.PollNow()
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:01")
+	watcher.PollNow()
+	expectQuiet(t, recorder)
+
+	if !primary.Home("alice") {
+		t.Fatal("Expected alice to be home")
+	}
+
+	// A standby taking over knows alice is home without alice arriving again
+	standby := netgear.NewPresence(alice)
+	standby.OnArrive = func(p netgear.Person) { t.Errorf("Unexpected arrival of %s", p.Name) }
+
+	takeover, takeoverRecorder := watch(t, server.Client(), netgear.WithStateStore(store), netgear.WithPresence(standby))
+	takeover.Subscribe(standby.Listener(), false)
+
+	takeover.PollNow()
+	expectQuiet(t, takeoverRecorder)
+
+	if !standby.Home("alice") {
+		t.Error("Expected the restored presence to have alice home")
+	}
+}
+
+func TestStateStoreReloadAfterGate(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetDevices(netgeartest.Device(t, "aa:bb:cc:00:00:01", "192.168.1.2", "phone"))
+
+	store := &netgear.RedisStateStore{Client: &memoryRedis{keys: map[string][]byte{}}, Key: "watcher"}
+
+	// The standby starts while another instance holds leadership, before any
+	// state has been saved
+	var leader atomic.Bool
+	skipped := make(chan struct{}, 1)
+	gate := func(int) bool {
+		if leader.Load() {
+			return true
+		}
+		select {
+		case skipped <- struct{}{}:
+		default:
+		}
+		return false
+	}
+
+	standby, standbyRecorder := watch(t, server.Client(), netgear.WithStateStore(store), netgear.WithBeforePoll(gate))
+	standby.PollNow()
+	<-skipped
+
+	primary, recorder := watch(t, server.Client(), netgear.WithStateStore(store))
+	primary.PollNow()
+	expectChange(t, recorder, netgear.DeviceAdded, "aa:bb:cc:00:00:01")
+	primary.Stop()
+
+	// Taking over loads the state saved by the primary meanwhile, the phone
+	// is not reported again
+	leader.Store(true)
+	standby.PollNow()
+	expectQuiet(t, standbyRecorder)
+}
@@ -0,0 +1,200 @@
+package netgear
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+const featureListAction soapAction = soapconst.DeviceInfoGetSupportFeatureListXML
+
+// Features gets the feature list published by the router, mapping feature
+// names to their version. Older firmware does not publish a feature list.
+func (c *Client) Features() (map[string]string, error) {
+	return c.features(context.Background())
+}
+
+func (c *Client) features(ctx context.Context) (map[string]string, error) {
+	type soapFeature struct {
+		XMLName xml.Name
+		Version string `xml:",chardata"`
+	}
+
+	type soapFeatures struct {
+		Features []soapFeature `xml:",any"`
+	}
+
+	type soapEnvelope struct {
+		List soapFeatures `xml:"Body>GetSupportFeatureListXMLResponse>newFeatureList>features"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := c.callContext(ctx, "get feature list", featureListAction, nil, &envelope); err != nil {
+		return nil, err
+	}
+
+	features := make(map[string]string, len(envelope.List.Features))
+	for _, feature := range envelope.List.Features {
+		features[feature.XMLName.Local] = strings.TrimSpace(feature.Version)
+	}
+
+	return features, nil
+}
+
+// Support indicates if an API is expected to work with the router
+type Support string
+
+// API support levels
+const (
+	Supported   Support = "supported"
+	Unsupported Support = "unsupported"
+	Unknown     Support = "unknown"
+)
+
+// APISupport is the expected support of a single library API
+type APISupport struct {
+	API     string
+	Support Support
+	Reason  string
+}
+
+// SupportMatrix lists which library APIs are expected to work with the
+// connected router
+type SupportMatrix struct {
+	Model    string
+	Firmware string
+	Features map[string]string
+	APIs     []APISupport
+}
+
+// Support looks up the expected support of an API by name, such as
+// "DetailedDevices"
+func (m *SupportMatrix) Support(api string) Support {
+	for _, s := range m.APIs {
+		if s.API == api {
+			return s.Support
+		}
+	}
+
+	return Unknown
+}
+
+// supportProbes check if an API is implemented using a read only call.
+// APIs which change the configuration are probed through a related read.
+var supportProbes = map[string]func(c *Client) error{
+	"Devices":         func(c *Client) error { _, err := c.DeviceList(); return err },
+	"DetailedDevices": func(c *Client) error { _, err := c.DetailedDevices(); return err },
+	"WAN":             func(c *Client) error { _, err := c.WAN(); return err },
+	"WirelessInfo":    func(c *Client) error { _, err := c.WirelessInfo(Band2G); return err },
+	"TrafficMeter":    func(c *Client) error { _, err := c.TrafficMeterOptions(); return err },
+	"PauseInternet":   func(c *Client) error { _, err := c.blockDeviceEnabled(); return err },
+}
+
+// apiFeatures are the entries of the routers feature list APIs depend on,
+// along with the minimum feature version
+var apiFeatures = map[string]struct{ feature, version string }{
+	"DetailedDevices": {"AttachedDevice", "2.0"},
+	"PauseInternet":   {"AccessControl", "1.0"},
+}
+
+// SupportMatrix determines which library APIs are expected to work with the
+// connected router. Known model quirks take precedence, followed by the
+// feature list published by the router. APIs neither decides are probed
+// with a read only call. The client must be logged in.
+func (c *Client) SupportMatrix() (*SupportMatrix, error) {
+	info, err := c.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := &SupportMatrix{Model: info.Model, Firmware: info.Firmware}
+
+	// The feature list is not published by all firmware
+	matrix.Features, _ = c.Features()
+
+	quirks := ModelQuirks(info.Model)
+
+	for api, probe := range supportProbes {
+		if quirk, ok := quirkSupport(quirks, api); ok {
+			matrix.APIs = append(matrix.APIs, APISupport{api, quirk.Support, quirk.Note})
+			continue
+		}
+
+		if support, ok := featureSupport(api, matrix.Features); ok {
+			matrix.APIs = append(matrix.APIs, support)
+			continue
+		}
+
+		matrix.APIs = append(matrix.APIs, probeSupport(c, api, probe))
+	}
+
+	sort.Slice(matrix.APIs, func(i, j int) bool {
+		return matrix.APIs[i].API < matrix.APIs[j].API
+	})
+
+	return matrix, nil
+}
+
+// featureSupport decides the support of an API from the routers feature
+// list, false when the list is not published or does not cover the API
+func featureSupport(api string, features map[string]string) (APISupport, bool) {
+	required, ok := apiFeatures[api]
+	if !ok || len(features) == 0 {
+		return APISupport{}, false
+	}
+
+	version, ok := features[required.feature]
+	if !ok {
+		return APISupport{api, Unsupported, fmt.Sprintf("The %s feature is not published by the router", required.feature)}, true
+	}
+
+	if !versionAtLeast(version, required.version) {
+		return APISupport{api, Unsupported, fmt.Sprintf("Requires %s %s, the router publishes %s", required.feature, required.version, version)}, true
+	}
+
+	return APISupport{api, Supported, fmt.Sprintf("The router publishes %s %s", required.feature, version)}, true
+}
+
+// versionAtLeast compares dotted numeric versions, such as 2.0. Versions
+// which are not numeric never satisfy the minimum.
+func versionAtLeast(version, min string) bool {
+	v, m := strings.Split(version, "."), strings.Split(min, ".")
+
+	for i := range m {
+		want, _ := strconv.Atoi(m[i])
+
+		have := 0
+		if i < len(v) {
+			var err error
+			if have, err = strconv.Atoi(v[i]); err != nil {
+				return false
+			}
+		}
+
+		if have != want {
+			return have > want
+		}
+	}
+
+	return true
+}
+
+func probeSupport(c *Client, api string, probe func(c *Client) error) APISupport {
+	err := probe(c)
+
+	respErr := &ResponseError{}
+	switch {
+	case err == nil:
+		return APISupport{api, Supported, ""}
+	case errors.As(err, &respErr) && respErr.Code == codeNotSupported:
+		return APISupport{api, Unsupported, "Not implemented by the firmware"}
+	default:
+		return APISupport{api, Unknown, err.Error()}
+	}
+}
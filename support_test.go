@@ -0,0 +1,51 @@
+package netgear_test
+
+import (
+	"strings"
+	"testing"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+func TestSupportMatrixFeatures(t *testing.T) {
+	fixtures, err := netgeartest.LoadFixtures("netgeartest/fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := netgeartest.Start(t)
+
+	// The mock implements every API, so only the feature list can mark
+	// detailed devices as unsupported
+	for _, f := range fixtures {
+		if f.Action == soapconst.DeviceInfoGetSupportFeatureListXML {
+			f.Payload = strings.Replace(f.Payload, "<AttachedDevice>2.0</AttachedDevice>", "<AttachedDevice>1.0</AttachedDevice>", 1)
+			server.ServeFixture(f)
+		}
+	}
+
+	client := server.Client()
+	if err := client.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	matrix, err := client.SupportMatrix()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if support := matrix.Support("DetailedDevices"); support != netgear.Unsupported {
+		t.Errorf("Expected DetailedDevices to be unsupported by AttachedDevice 1.0, got %s", support)
+	}
+
+	if support := matrix.Support("PauseInternet"); support != netgear.Supported {
+		t.Errorf("Expected PauseInternet to be supported by AccessControl, got %s", support)
+	}
+
+	// APIs not covered by the feature list are still probed
+	if support := matrix.Support("Devices"); support != netgear.Supported {
+		t.Errorf("Expected Devices to be probed as supported, got %s", support)
+	}
+}
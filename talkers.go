@@ -0,0 +1,82 @@
+package netgear
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Talker is the traffic of a single device. Volumes are in megabytes, as
+// reported by the detailed device list.
+type Talker struct {
+	Device   AttachedDevice
+	Upload   float64
+	Download float64
+	Total    float64
+
+	// Share is the fraction of the traffic of all devices used by this
+	// device, from 0 to 1
+	Share float64
+}
+
+// TopTalkers ranks the attached devices by their traffic
+type TopTalkers struct {
+	// Talkers are ordered by total traffic, largest first
+	Talkers []Talker
+
+	// Totals of the traffic of all attached devices, including those not
+	// listed in Talkers
+	Upload   float64
+	Download float64
+	Total    float64
+}
+
+// TopTalkers gets the n attached devices using the most traffic, or every
+// device when n is zero. Traffic is only reported by the detailed device
+// list, which is not supported by older firmware.
+func (c *Client) TopTalkers(n int) (*TopTalkers, error) {
+	devices, err := c.DetailedDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	return rankTalkers(devices, n), nil
+}
+
+func rankTalkers(devices []AttachedDevice, n int) *TopTalkers {
+	top := &TopTalkers{Talkers: make([]Talker, 0, len(devices))}
+
+	for _, dev := range devices {
+		top.Talkers = append(top.Talkers, Talker{
+			Device:   dev,
+			Upload:   dev.Upload,
+			Download: dev.Download,
+			Total:    dev.Upload + dev.Download,
+		})
+
+		top.Upload += dev.Upload
+		top.Download += dev.Download
+	}
+
+	top.Total = top.Upload + top.Download
+
+	sort.SliceStable(top.Talkers, func(i, j int) bool {
+		a, b := top.Talkers[i], top.Talkers[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+
+		return bytes.Compare(a.Device.MAC, b.Device.MAC) < 0
+	})
+
+	if top.Total > 0 {
+		for i := range top.Talkers {
+			top.Talkers[i].Share = top.Talkers[i].Total / top.Total
+		}
+	}
+
+	if n > 0 && n < len(top.Talkers) {
+		top.Talkers = top.Talkers[:n]
+	}
+
+	return top
+}
@@ -0,0 +1,183 @@
+package netgear
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TrustStore records the certificate fingerprint trusted for each router,
+// keyed by the host and port connected to. Load returns nil when no
+// certificate has been trusted yet.
+type TrustStore interface {
+	Load(addr string) ([]byte, error)
+	Save(addr string, fingerprint []byte) error
+}
+
+// CertificateChangedError is returned when a router presents a different
+// certificate than the one trusted on first use. This happens when the
+// router was reset or replaced, but may also be an interception attempt.
+type CertificateChangedError struct {
+	Addr      string
+	Trusted   []byte
+	Presented []byte
+}
+
+func (e *CertificateChangedError) Error() string {
+	return fmt.Sprintf(
+		"Certificate of %s has changed, trusted SHA-256 fingerprint %s but was presented %s. Remove the trusted fingerprint if the router was reset or replaced",
+		e.Addr, hex.EncodeToString(e.Trusted), hex.EncodeToString(e.Presented),
+	)
+}
+
+// WithTrustOnFirstUse connects to the router using HTTPS, trusting the
+// certificate presented on the first connection. The fingerprint of the
+// certificate is recorded in the store and later connections fail with a
+// CertificateChangedError if it changes. This suits routers using self-signed
+// certificates, which can not otherwise be verified. Routers usually serve
+// SOAP over HTTPS on port 5555.
+func WithTrustOnFirstUse(store TrustStore) ClientOption {
+	return func(c *Client) {
+		c.dial.trust = store
+	}
+}
+
+// CertificateFingerprint is the SHA-256 fingerprint of a DER encoded
+// certificate, as recorded in a TrustStore
+func CertificateFingerprint(der []byte) []byte {
+	sum := sha256.Sum256(der)
+	return sum[:]
+}
+
+// dialTLSContext establishes a TLS connection to the router, verifying the
+// certificate against the trust store
+func (d dialConfig) dialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := d.dialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// The certificate chain is deliberately not verified, the fingerprint
+	// check below replaces it
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			return d.verifyFingerprint(addr, state)
+		},
+	})
+
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+func (d dialConfig) verifyFingerprint(addr string, state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("Router at %s presented no certificate", addr)
+	}
+
+	presented := CertificateFingerprint(state.PeerCertificates[0].Raw)
+
+	trusted, err := d.trust.Load(addr)
+	if err != nil {
+		return err
+	}
+
+	if trusted == nil {
+		return d.trust.Save(addr, presented)
+	}
+
+	if !bytes.Equal(trusted, presented) {
+		return &CertificateChangedError{Addr: addr, Trusted: trusted, Presented: presented}
+	}
+
+	return nil
+}
+
+// FileTrustStore is a TrustStore saving fingerprints to a JSON file, mapping
+// each host and port to a hex encoded fingerprint. The file may be edited by
+// hand to remove a fingerprint after a router is reset.
+type FileTrustStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func (s *FileTrustStore) read() (map[string]string, error) {
+	fingerprints := map[string]string{}
+
+	contents, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fingerprints, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(contents, &fingerprints); err != nil {
+		return nil, fmt.Errorf("Unable to parse trust store %s: %s", s.Path, err)
+	}
+
+	return fingerprints, nil
+}
+
+// Load implements TrustStore
+func (s *FileTrustStore) Load(addr string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fingerprints, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, ok := fingerprints[addr]
+	if !ok {
+		return nil, nil
+	}
+
+	return hex.DecodeString(fingerprint)
+}
+
+// Save implements TrustStore
+func (s *FileTrustStore) Save(addr string, fingerprint []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fingerprints, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	fingerprints[addr] = hex.EncodeToString(fingerprint)
+
+	contents, err := json.MarshalIndent(fingerprints, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.Path, contents, 0o600)
+}
@@ -0,0 +1,301 @@
+package netgear
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+const (
+	trafficMeterAction        soapAction = soapconst.DeviceConfigGetTrafficMeterStatistics
+	trafficMeterOptionsAction soapAction = soapconst.DeviceConfigGetTrafficMeterOptions
+)
+
+// TrafficMeterOptions is the traffic meter configuration of the router
+type TrafficMeterOptions struct {
+	// ControlOption is what the monthly limit applies to, such as
+	// "No limit", "Download only", or "Both directions"
+	ControlOption string
+
+	// MonthlyLimit is the monthly data limit in megabytes
+	MonthlyLimit float64
+
+	// The traffic counters restart at this time of day, and the monthly
+	// counters on this day of the month
+	RestartDay    int
+	RestartHour   int
+	RestartMinute int
+}
+
+// TrafficPeriod is the traffic recorded by the router over a period. Volumes
+// are in megabytes.
+type TrafficPeriod struct {
+	Start time.Time
+	End   time.Time
+
+	ConnectionTime time.Duration
+	Upload         float64
+	Download       float64
+
+	// Daily averages are only reported for periods longer than a day
+	AvgUpload   float64
+	AvgDownload float64
+}
+
+// TrafficMeter is the traffic recorded by the router for each of the periods
+// it reports
+type TrafficMeter struct {
+	Today     TrafficPeriod
+	Yesterday TrafficPeriod
+	Week      TrafficPeriod
+	Month     TrafficPeriod
+	LastMonth TrafficPeriod
+}
+
+// TrafficMeterOptions gets the traffic meter configuration
+func (c *Client) TrafficMeterOptions() (*TrafficMeterOptions, error) {
+	type soapOptions struct {
+		ControlOption string `xml:"NewControlOption"`
+		MonthlyLimit  string `xml:"NewMonthlyLimit"`
+		RestartHour   string `xml:"RestartHour"`
+		RestartMinute string `xml:"RestartMinute"`
+		RestartDay    string `xml:"RestartDay"`
+	}
+
+	type soapEnvelope struct {
+		Options soapOptions `xml:"Body>GetTrafficMeterOptionsResponse"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := c.call("get traffic meter options", trafficMeterOptionsAction, nil, &envelope); err != nil {
+		return nil, err
+	}
+
+	o := envelope.Options
+	options := &TrafficMeterOptions{ControlOption: o.ControlOption}
+
+	var err error
+	if options.MonthlyLimit, err = parseTrafficVolume(o.MonthlyLimit); err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		value string
+		dest  *int
+	}{
+		{o.RestartDay, &options.RestartDay},
+		{o.RestartHour, &options.RestartHour},
+		{o.RestartMinute, &options.RestartMinute},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if *field.dest, err = strconv.Atoi(strings.TrimSpace(field.value)); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.RestartDay == 0 {
+		options.RestartDay = 1
+	}
+
+	return options, nil
+}
+
+// TrafficMeter gets the traffic recorded by the router. The start and end of
+// each period are derived from the restart time and day of the traffic meter
+// options, with weeks starting on Sunday.
+func (c *Client) TrafficMeter() (*TrafficMeter, error) {
+	options, err := c.TrafficMeterOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.trafficMeter(options)
+}
+
+func (c *Client) trafficMeter(options *TrafficMeterOptions) (*TrafficMeter, error) {
+	type soapStats struct {
+		TodayConnectionTime     string `xml:"NewTodayConnectionTime"`
+		TodayUpload             string `xml:"NewTodayUpload"`
+		TodayDownload           string `xml:"NewTodayDownload"`
+		YesterdayConnectionTime string `xml:"NewYesterdayConnectionTime"`
+		YesterdayUpload         string `xml:"NewYesterdayUpload"`
+		YesterdayDownload       string `xml:"NewYesterdayDownload"`
+		WeekConnectionTime      string `xml:"NewWeekConnectionTime"`
+		WeekUpload              string `xml:"NewWeekUpload"`
+		WeekDownload            string `xml:"NewWeekDownload"`
+		MonthConnectionTime     string `xml:"NewMonthConnectionTime"`
+		MonthUpload             string `xml:"NewMonthUpload"`
+		MonthDownload           string `xml:"NewMonthDownload"`
+		LastMonthConnectionTime string `xml:"NewLastMonthConnectionTime"`
+		LastMonthUpload         string `xml:"NewLastMonthUpload"`
+		LastMonthDownload       string `xml:"NewLastMonthDownload"`
+	}
+
+	type soapEnvelope struct {
+		Stats soapStats `xml:"Body>GetTrafficMeterStatisticsResponse"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := c.call("get traffic meter", trafficMeterAction, nil, &envelope); err != nil {
+		return nil, err
+	}
+
+	s := envelope.Stats
+	bounds := trafficPeriodBounds(options, time.Now())
+	meter := &TrafficMeter{}
+
+	for _, period := range []struct {
+		dest     *TrafficPeriod
+		bounds   [2]time.Time
+		connTime string
+		upload   string
+		download string
+	}{
+		{&meter.Today, bounds.today, s.TodayConnectionTime, s.TodayUpload, s.TodayDownload},
+		{&meter.Yesterday, bounds.yesterday, s.YesterdayConnectionTime, s.YesterdayUpload, s.YesterdayDownload},
+		{&meter.Week, bounds.week, s.WeekConnectionTime, s.WeekUpload, s.WeekDownload},
+		{&meter.Month, bounds.month, s.MonthConnectionTime, s.MonthUpload, s.MonthDownload},
+		{&meter.LastMonth, bounds.lastMonth, s.LastMonthConnectionTime, s.LastMonthUpload, s.LastMonthDownload},
+	} {
+		p, err := parseTrafficPeriod(period.connTime, period.upload, period.download)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Start, p.End = period.bounds[0], period.bounds[1]
+		*period.dest = p
+	}
+
+	return meter, nil
+}
+
+type trafficBounds struct {
+	today     [2]time.Time
+	yesterday [2]time.Time
+	week      [2]time.Time
+	month     [2]time.Time
+	lastMonth [2]time.Time
+}
+
+// trafficPeriodBounds computes the start and end of each traffic meter
+// period containing now. Current periods end at now.
+func trafficPeriodBounds(options *TrafficMeterOptions, now time.Time) trafficBounds {
+	restart := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, options.RestartHour, options.RestartMinute, 0, 0, now.Location())
+	}
+
+	dayStart := restart(now.Year(), now.Month(), now.Day())
+	if dayStart.After(now) {
+		dayStart = dayStart.AddDate(0, 0, -1)
+	}
+
+	weekStart := dayStart.AddDate(0, 0, -int(dayStart.Weekday()))
+
+	monthStart := trafficMonthRestart(options, now.Year(), now.Month(), now.Location())
+	if monthStart.After(now) {
+		monthStart = trafficMonthRestart(options, now.Year(), now.Month()-1, now.Location())
+	}
+
+	lastMonthStart := trafficMonthRestart(options, monthStart.Year(), monthStart.Month()-1, now.Location())
+
+	return trafficBounds{
+		today:     [2]time.Time{dayStart, now},
+		yesterday: [2]time.Time{dayStart.AddDate(0, 0, -1), dayStart},
+		week:      [2]time.Time{weekStart, now},
+		month:     [2]time.Time{monthStart, now},
+		lastMonth: [2]time.Time{lastMonthStart, monthStart},
+	}
+}
+
+// trafficMonthRestart is when the monthly traffic counters restart in the
+// given month. Restart days past the end of a month, such as the 31st,
+// restart on the last day of shorter months.
+func trafficMonthRestart(options *TrafficMeterOptions, year int, month time.Month, loc *time.Location) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	day := options.RestartDay
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(year, month, day, options.RestartHour, options.RestartMinute, 0, 0, loc)
+}
+
+// parseTrafficPeriod parses the statistics reported for a period. Periods
+// longer than a day report volumes as <total>/<daily average>.
+func parseTrafficPeriod(connTime, upload, download string) (TrafficPeriod, error) {
+	p := TrafficPeriod{}
+
+	var err error
+	if p.ConnectionTime, err = parseConnectionTime(connTime); err != nil {
+		return p, err
+	}
+
+	if p.Upload, p.AvgUpload, err = parseTrafficTotal(upload); err != nil {
+		return p, err
+	}
+
+	if p.Download, p.AvgDownload, err = parseTrafficTotal(download); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func parseTrafficTotal(value string) (total, avg float64, err error) {
+	totalStr, avgStr, hasAvg := strings.Cut(value, "/")
+
+	if total, err = parseTrafficVolume(totalStr); err != nil {
+		return 0, 0, err
+	}
+
+	if hasAvg {
+		if avg, err = parseTrafficVolume(avgStr); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return total, avg, nil
+}
+
+// parseTrafficVolume parses a volume in megabytes. Volumes may include
+// thousands separators, and are reported as "--" when unavailable.
+func parseTrafficVolume(value string) (float64, error) {
+	value = strings.TrimSpace(strings.ReplaceAll(value, ",", ""))
+	value = strings.TrimSpace(strings.TrimSuffix(value, "MB"))
+
+	if value == "" || strings.Contains(value, "--") {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// parseConnectionTime parses a connection time formatted as <hours>:<minutes>
+func parseConnectionTime(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.Contains(value, "--") {
+		return 0, nil
+	}
+
+	hours, minutes, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, fmt.Errorf("Connection time is not formatted as hours:minutes: %q", value)
+	}
+
+	h, err := strconv.Atoi(strings.TrimSpace(hours))
+	if err != nil {
+		return 0, err
+	}
+
+	m, err := strconv.Atoi(strings.TrimSpace(minutes))
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
@@ -0,0 +1,37 @@
+package netgear
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrafficPeriodRestarted(t *testing.T) {
+	// The meter restarts on the 16th at 06:30
+	options := &TrafficMeterOptions{RestartDay: 16, RestartHour: 6, RestartMinute: 30}
+
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		last, now time.Time
+		restarted bool
+	}{
+		{"across the restart", at(16, 6, 25), at(16, 6, 35), true},
+		{"before the restart", at(16, 6, 0), at(16, 6, 20), false},
+		{"after the restart", at(16, 6, 40), at(16, 6, 50), false},
+		{"across midnight", at(15, 23, 55), at(16, 0, 5), false},
+		{"router clock ahead", at(16, 6, 25), at(16, 6, 29), true},
+		{"across the previous restart", at(16, 6, 25), at(20, 12, 0), true},
+		{"late in the period", at(20, 12, 0), at(20, 12, 10), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if restarted := trafficPeriodRestarted(options, tt.last, tt.now); restarted != tt.restarted {
+				t.Errorf("Expected restarted %t, got %t", tt.restarted, restarted)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package netgear
+
+import (
+	"sync"
+	"time"
+)
+
+// maxTrafficRate is the largest plausible traffic rate in megabytes per
+// second, well above any consumer connection
+const maxTrafficRate = 10000
+
+// TrafficSample is the traffic through the router since the previous sample.
+// Volumes are in megabytes and rates in megabytes per second.
+type TrafficSample struct {
+	Time         time.Time
+	Upload       float64
+	Download     float64
+	UploadRate   float64
+	DownloadRate float64
+
+	// Reset indicates the routers counters restarted since the previous
+	// sample, such as after a reboot. Traffic between the last sample and
+	// the reset is lost. The counters rolling over at the daily restart time
+	// or the end of the traffic meter period is not a reset.
+	Reset bool
+}
+
+// TrafficListener is a callback for each traffic sample
+type TrafficListener func(*TrafficSample, error)
+
+// TrafficWatcher polls the routers traffic meter, reporting the traffic
+// between each poll
+type TrafficWatcher struct {
+	client   *Client
+	ticker   *time.Ticker
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+	fn       TrafficListener
+
+	// options holds the restart time of the traffic meter, read from the
+	// router on the first poll
+	options *TrafficMeterOptions
+
+	upload        Counter
+	download      Counter
+	monthUpload   Counter
+	monthDownload Counter
+	lastAt        time.Time
+}
+
+// WatchTraffic starts polling the routers traffic meter. The first sample is
+// reported on the second poll. The restart time of the meter is read when
+// first polled, changes to it are not picked up by a running watcher.
+func (c *Client) WatchTraffic(poll time.Duration, fn TrafficListener) *TrafficWatcher {
+	w := &TrafficWatcher{
+		client:        c,
+		ticker:        time.NewTicker(poll),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+		fn:            fn,
+		upload:        Counter{MaxRate: maxTrafficRate},
+		download:      Counter{MaxRate: maxTrafficRate},
+		monthUpload:   Counter{MaxRate: maxTrafficRate},
+		monthDownload: Counter{MaxRate: maxTrafficRate},
+	}
+
+	c.trackWatcher(w)
+
+	go w.watch()
+
+	return w
+}
+
+// Stop stops the watcher from polling the router, waiting for a poll in
+// progress to finish. Stop must not be called from within the listener.
+func (w *TrafficWatcher) Stop() {
+	w.halt()
+	w.wait()
+}
+
+func (w *TrafficWatcher) halt() {
+	w.stopOnce.Do(func() {
+		w.ticker.Stop()
+		close(w.done)
+		w.client.untrackWatcher(w)
+	})
+}
+
+func (w *TrafficWatcher) wait() {
+	<-w.stopped
+}
+
+// stopping reports if the watcher has been asked to stop
+func (w *TrafficWatcher) stopping() bool {
+	select {
+	case <-w.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// report calls the listener with an error, unless it was caused by stopping
+// the watcher, such as a call cancelled by closing the client
+func (w *TrafficWatcher) report(err error) {
+	if !w.stopping() {
+		w.fn(nil, err)
+	}
+}
+
+func (w *TrafficWatcher) watch() {
+	defer close(w.stopped)
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.ticker.C:
+			w.poll()
+		}
+	}
+}
+
+func (w *TrafficWatcher) poll() {
+	if err := w.client.Login(); err != nil {
+		w.report(err)
+		return
+	}
+
+	if w.options == nil {
+		options, err := w.client.TrafficMeterOptions()
+		if err != nil {
+			w.report(err)
+			return
+		}
+		w.options = options
+	}
+
+	meter, err := w.client.TrafficMeter()
+	if err != nil {
+		w.report(err)
+		return
+	}
+
+	// Todays counters are the most granular the router reports, they reset
+	// when the day ends and when the router reboots
+	now := time.Now()
+	upload, uploadState := w.upload.Add(meter.Today.Upload, now)
+	download, downloadState := w.download.Add(meter.Today.Download, now)
+	monthUpload, monthUploadState := w.monthUpload.Add(meter.Month.Upload, now)
+	monthDownload, monthDownloadState := w.monthDownload.Add(meter.Month.Download, now)
+
+	lastAt := w.lastAt
+	w.lastAt = now
+
+	if uploadState == CounterFirst {
+		return
+	}
+
+	reset := uploadState == CounterReset || downloadState == CounterReset
+
+	// Todays counters restarting while the months counters carry on is the
+	// daily restart, the months counters also cover the traffic from before
+	// it. Both restarting across the monthly restart time is the traffic
+	// meter period ending.
+	if reset {
+		switch {
+		case monthUploadState == CounterIncreased && monthDownloadState == CounterIncreased:
+			upload, download, reset = monthUpload, monthDownload, false
+		case trafficPeriodRestarted(w.options, lastAt, now):
+			reset = false
+		}
+	}
+
+	sample := &TrafficSample{
+		Time:     now,
+		Upload:   upload,
+		Download: download,
+		Reset:    reset,
+	}
+
+	if elapsed := now.Sub(lastAt).Seconds(); elapsed > 0 {
+		sample.UploadRate = upload / elapsed
+		sample.DownloadRate = download / elapsed
+	}
+
+	w.fn(sample, nil)
+}
+
+// restartSlack allows for the routers clock being slightly off from the
+// local clock when matching counter resets to the meters restart time
+const restartSlack = 2 * time.Minute
+
+// trafficPeriodRestarted reports if the monthly traffic meter period
+// restarted between the last and the current poll
+func trafficPeriodRestarted(options *TrafficMeterOptions, last, now time.Time) bool {
+	latest := now.Add(restartSlack)
+
+	restart := trafficMonthRestart(options, latest.Year(), latest.Month(), latest.Location())
+	if restart.After(latest) {
+		restart = trafficMonthRestart(options, latest.Year(), latest.Month()-1, latest.Location())
+	}
+
+	return restart.After(last.Add(-restartSlack))
+}
@@ -0,0 +1,92 @@
+package netgear_test
+
+import (
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+	"go.evanpurkhiser.com/netgear/netgeartest"
+)
+
+func testMeter(today, month float64) netgear.TrafficMeter {
+	return netgear.TrafficMeter{
+		Today: netgear.TrafficPeriod{Upload: today, Download: today},
+		Month: netgear.TrafficPeriod{Upload: month, Download: month},
+	}
+}
+
+func TestTrafficWatcherRollover(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetTrafficMeter(netgear.TrafficMeterOptions{}, testMeter(100, 1000))
+
+	samples := make(chan *netgear.TrafficSample, 100)
+
+	watcher := server.Client().WatchTraffic(10*time.Millisecond, func(s *netgear.TrafficSample, err error) {
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		samples <- s
+	})
+	defer watcher.Stop()
+
+	// next waits for a sample reporting the changed meter
+	next := func() *netgear.TrafficSample {
+		for {
+			select {
+			case s := <-samples:
+				if s.Upload != 0 || s.Reset {
+					return s
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("Timed out waiting for a traffic sample")
+			}
+		}
+	}
+
+	// Wait for the first sample so the initial meter has been seen
+	select {
+	case <-samples:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for a traffic sample")
+	}
+
+	// Todays counters restart while the months carry on
+	server.SetTrafficMeter(netgear.TrafficMeterOptions{}, testMeter(5, 1010))
+
+	if s := next(); s.Reset || s.Upload != 10 || s.Download != 10 {
+		t.Errorf("Expected a 10MB rollover sample without a reset, got %+v", s)
+	}
+
+	// Every counter restarting on the same day is the router resetting
+	server.SetTrafficMeter(netgear.TrafficMeterOptions{}, testMeter(1, 1))
+
+	if s := next(); !s.Reset || s.Upload != 1 {
+		t.Errorf("Expected a 1MB reset sample, got %+v", s)
+	}
+}
+
+func TestTrafficWatcherClose(t *testing.T) {
+	server := netgeartest.Start(t)
+	server.SetTrafficMeter(netgear.TrafficMeterOptions{}, testMeter(100, 1000))
+	server.SetLatency(200 * time.Millisecond)
+
+	client := server.Client()
+
+	errs := make(chan error, 10)
+	client.WatchTraffic(10*time.Millisecond, func(s *netgear.TrafficSample, err error) {
+		if err != nil {
+			errs <- err
+		}
+	})
+
+	// Close while a poll is waiting on the router
+	time.Sleep(50 * time.Millisecond)
+	client.Close()
+
+	select {
+	case err := <-errs:
+		t.Errorf("Expected the cancelled poll not to be reported, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,63 @@
+package netgear
+
+import (
+	"net"
+	"strings"
+
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+const wanInfoAction soapAction = soapconst.WANIPConnectionGetInfo
+
+// WANInfo describes the routers internet connection
+type WANInfo struct {
+	ExternalIP     net.IP
+	SubnetMask     net.IP
+	Gateway        net.IP
+	DNSServers     []net.IP
+	MAC            net.HardwareAddr
+	AddressingType string
+}
+
+// WAN gets the routers internet connection details
+func (c *Client) WAN() (*WANInfo, error) {
+	type soapWAN struct {
+		ExternalIP     string `xml:"NewExternalIPAddress"`
+		SubnetMask     string `xml:"NewSubnetMask"`
+		Gateway        string `xml:"NewDefaultGateway"`
+		DNSServers     string `xml:"NewDNSServers"`
+		MAC            string `xml:"NewMACAddress"`
+		AddressingType string `xml:"NewAddressingType"`
+	}
+
+	type soapEnvelope struct {
+		WAN soapWAN `xml:"Body>GetInfoResponse"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := c.call("get WAN info", wanInfoAction, nil, &envelope); err != nil {
+		return nil, err
+	}
+
+	w := envelope.WAN
+
+	// The MAC address is omitted by some firmware
+	mac, _ := net.ParseMAC(w.MAC)
+
+	info := &WANInfo{
+		ExternalIP:     net.ParseIP(strings.TrimSpace(w.ExternalIP)),
+		SubnetMask:     net.ParseIP(strings.TrimSpace(w.SubnetMask)),
+		Gateway:        net.ParseIP(strings.TrimSpace(w.Gateway)),
+		MAC:            mac,
+		AddressingType: w.AddressingType,
+	}
+
+	// DNS servers are separated by spaces or commas
+	for _, addr := range strings.FieldsFunc(w.DNSServers, func(r rune) bool { return r == ' ' || r == ',' }) {
+		if ip := net.ParseIP(addr); ip != nil {
+			info.DNSServers = append(info.DNSServers, ip)
+		}
+	}
+
+	return info, nil
+}
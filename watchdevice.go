@@ -0,0 +1,99 @@
+package netgear
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// sharedWatcher is a watcher shared between WatchDevice calls polling at the
+// same interval
+type sharedWatcher struct {
+	watcher *Watcher
+	refs    int
+}
+
+// WatchDevice reports changes for a single device. The device is reported as
+// DeviceSeen immediately if it is currently known to be attached, followed by
+// DeviceAdded, DeviceRemoved and DeviceUpdated changes as it comes online,
+// goes offline, or changes attributes. Poll errors are not reported.
+//
+// Calls polling at the same interval share a single watcher. The returned
+// channel is closed once the context is done or the client is closed, and is
+// returned already closed if the client was closed beforehand. It must be
+// consumed promptly as it blocks reporting changes to other watchers of the
+// same interval.
+func (c *Client) WatchDevice(ctx context.Context, mac net.HardwareAddr, interval time.Duration) <-chan ChangedDevice {
+	changes := make(chan ChangedDevice, 1)
+	target := mac.String()
+
+	closing, err := c.lifecycle.closing()
+	if err != nil {
+		close(changes)
+		return changes
+	}
+
+	listener := func(change *ChangedDevice, err error) {
+		if err != nil || change.Device.MAC.String() != target {
+			return
+		}
+
+		select {
+		case changes <- *change:
+		case <-ctx.Done():
+		case <-closing.Done():
+		}
+	}
+
+	watcher := c.acquireSharedWatcher(interval)
+	unsubscribe := watcher.Subscribe(listener, true)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-closing.Done():
+		}
+		unsubscribe()
+		c.releaseSharedWatcher(interval)
+		close(changes)
+	}()
+
+	return changes
+}
+
+func (c *Client) acquireSharedWatcher(interval time.Duration) *Watcher {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.shared == nil {
+		c.shared = map[time.Duration]*sharedWatcher{}
+	}
+
+	shared, ok := c.shared[interval]
+	if !ok {
+		noop := func(*ChangedDevice, error) {}
+		shared = &sharedWatcher{watcher: c.Watch(interval, noop, WithDeviceUpdates())}
+		c.shared[interval] = shared
+	}
+
+	shared.refs++
+
+	return shared.watcher
+}
+
+func (c *Client) releaseSharedWatcher(interval time.Duration) {
+	c.mu.Lock()
+	shared := c.shared[interval]
+	shared.refs--
+
+	stop := shared.refs == 0
+	if stop {
+		delete(c.shared, interval)
+	}
+	c.mu.Unlock()
+
+	// Stopping waits for a poll in progress, which needs the client lock
+	if stop {
+		shared.watcher.Stop()
+	}
+}
@@ -0,0 +1,119 @@
+package netgear
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietWindow is a daily period during which the watcher does not poll, such
+// as the nightly auto-maintenance of the router. Start and End are wall
+// clock times of day, as durations since midnight. A window ending before it
+// starts spans midnight.
+type QuietWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseQuietWindow parses a window formatted as <start>-<end> using 24 hour
+// times, such as 02:00-05:00
+func ParseQuietWindow(value string) (QuietWindow, error) {
+	start, end, ok := strings.Cut(value, "-")
+	if !ok {
+		return QuietWindow{}, fmt.Errorf("Quiet window %q is not formatted as <start>-<end>", value)
+	}
+
+	window := QuietWindow{}
+
+	for _, field := range []struct {
+		value string
+		dest  *time.Duration
+	}{
+		{start, &window.Start},
+		{end, &window.End},
+	} {
+		t, err := time.Parse("15:04", strings.TrimSpace(field.value))
+		if err != nil {
+			return QuietWindow{}, fmt.Errorf("Quiet window %q has an invalid time: %s", value, err)
+		}
+
+		*field.dest = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+
+	return window, nil
+}
+
+// Contains checks if the window contains the time of day of t. The wall
+// clock time is compared, so windows keep their times on days daylight
+// saving time starts or ends.
+func (q QuietWindow) Contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	if q.End < q.Start {
+		return offset >= q.Start || offset < q.End
+	}
+
+	return offset >= q.Start && offset < q.End
+}
+
+func (q QuietWindow) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+
+	return clock(q.Start) + "-" + clock(q.End)
+}
+
+// WithQuietWindows skips polling during the windows. Routers often take a
+// while to relist every device after their nightly maintenance, so when
+// polling resumes devices missing from the router are assumed to still be
+// attached until settle has passed. Devices which reappear while settling
+// are never reported as removed.
+func WithQuietWindows(settle time.Duration, windows ...QuietWindow) WatchOption {
+	return func(w *Watcher) {
+		w.quietWindows = windows
+		w.settle = settle
+	}
+}
+
+// quiet checks if polling is paused by a quiet window, starting the settle
+// period once the window has passed. Must be called with the lock held.
+func (w *Watcher) quiet(now time.Time) bool {
+	for _, window := range w.quietWindows {
+		if window.Contains(now) {
+			w.quieted = true
+			return true
+		}
+	}
+
+	if w.quieted {
+		w.quieted = false
+		w.settleUntil = now.Add(w.settle)
+	}
+
+	return false
+}
+
+// retainMissing carries the known devices missing from an updated list of
+// devices over onto it while settling after a quiet window. Must be called
+// with the lock held.
+func (w *Watcher) retainMissing(devices []AttachedDevice, now time.Time) []AttachedDevice {
+	if !now.Before(w.settleUntil) {
+		return devices
+	}
+
+	if w.index == nil {
+		w.index = indexDevices(w.devices, w.key)
+	}
+
+	present := indexDevices(devices, w.key)
+	for key, i := range w.index {
+		if _, ok := present[key]; !ok {
+			devices = append(devices, w.devices[i])
+		}
+	}
+
+	sortDevices(devices)
+
+	return devices
+}
@@ -0,0 +1,37 @@
+package netgear_test
+
+import (
+	"testing"
+	"time"
+
+	"go.evanpurkhiser.com/netgear"
+)
+
+func TestQuietWindowDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("Time zone database unavailable: %s", err)
+	}
+
+	window, err := netgear.ParseQuietWindow("04:00-05:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Daylight saving time starts and ends at 02:00 on these days, so the
+	// day is an hour shorter or longer than usual
+	for _, day := range []time.Time{
+		time.Date(2022, 3, 13, 0, 0, 0, 0, loc),
+		time.Date(2022, 11, 6, 0, 0, 0, 0, loc),
+	} {
+		inside := time.Date(day.Year(), day.Month(), day.Day(), 4, 30, 0, 0, loc)
+		if !window.Contains(inside) {
+			t.Errorf("Expected %s to be inside %s", inside, window)
+		}
+
+		outside := time.Date(day.Year(), day.Month(), day.Day(), 3, 30, 0, 0, loc)
+		if window.Contains(outside) {
+			t.Errorf("Expected %s to be outside %s", outside, window)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package netgear
+
+import (
+	"fmt"
+
+	"go.evanpurkhiser.com/netgear/soapconst"
+)
+
+// Band is a wireless radio band
+type Band string
+
+// Wireless bands
+const (
+	Band2G Band = "2g"
+	Band5G Band = "5g"
+)
+
+// Each band has its own action on the WLANConfiguration service
+var wirelessInfoActions = map[Band]soapAction{
+	Band2G: soapconst.WLANConfigurationGetInfo,
+	Band5G: soapconst.WLANConfigurationGet5GInfo,
+}
+
+// WirelessInfo describes the configuration of a wireless band
+type WirelessInfo struct {
+	Enabled  bool
+	SSID     string
+	Channel  string
+	Mode     string
+	Security string
+	Region   string
+	Status   string
+}
+
+// WirelessInfo gets the configuration of a wireless band
+func (c *Client) WirelessInfo(band Band) (*WirelessInfo, error) {
+	action, ok := wirelessInfoActions[band]
+	if !ok {
+		return nil, fmt.Errorf("Unknown wireless band %q", band)
+	}
+
+	type soapWireless struct {
+		Enabled  string `xml:"NewEnable"`
+		SSID     string `xml:"NewSSID"`
+		Channel  string `xml:"NewChannel"`
+		Mode     string `xml:"NewWirelessMode"`
+		Security string `xml:"NewBasicEncryptions"`
+		Region   string `xml:"NewRegion"`
+		Status   string `xml:"NewStatus"`
+	}
+
+	type soapEnvelope struct {
+		Body struct {
+			Info   soapWireless `xml:"GetInfoResponse"`
+			Info5G soapWireless `xml:"Get5GInfoResponse"`
+		} `xml:"Body"`
+	}
+
+	envelope := soapEnvelope{}
+	if err := c.call("get wireless info", action, nil, &envelope); err != nil {
+		return nil, err
+	}
+
+	w := envelope.Body.Info
+	if band == Band5G {
+		w = envelope.Body.Info5G
+	}
+
+	return &WirelessInfo{
+		Enabled:  w.Enabled == "1",
+		SSID:     w.SSID,
+		Channel:  w.Channel,
+		Mode:     w.Mode,
+		Security: w.Security,
+		Region:   w.Region,
+		Status:   w.Status,
+	}, nil
+}